@@ -0,0 +1,306 @@
+// Package amp checks built pages against the parts of the AMP HTML spec the
+// site relies on, see https://www.ampproject.org/docs/reference/spec
+package amp
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	runtimeURL = "https://cdn.ampproject.org/v0.js"
+	// scripts may only be loaded from the AMP cdn
+	scriptPrefix = "https://cdn.ampproject.org/"
+	// stylesheets may only be loaded from allowed font providers
+	fontPrefix = "https://fonts.googleapis.com/"
+	// size limit of the page's <style amp-custom>
+	MaxCustomCSS = 50000
+)
+
+// HTML elements allowed in an AMP page, amp-* elements aside
+var allowedElements = setOf(
+	"html", "head", "title", "meta", "link", "style", "script", "noscript", "body",
+	"a", "abbr", "address", "article", "aside", "b", "bdi", "bdo", "blockquote",
+	"br", "button", "caption", "cite", "code", "col", "colgroup", "data", "dd",
+	"del", "details", "dfn", "div", "dl", "dt", "em", "fieldset", "figcaption",
+	"figure", "footer", "form", "h1", "h2", "h3", "h4", "h5", "h6", "header",
+	"hgroup", "hr", "i", "input", "ins", "kbd", "label", "legend", "li", "main",
+	"mark", "nav", "ol", "optgroup", "option", "p", "pre", "q", "rp", "rt",
+	"ruby", "s", "samp", "section", "select", "small", "span", "strong", "sub",
+	"summary", "sup", "svg", "table", "tbody", "td", "template", "textarea",
+	"tfoot", "th", "thead", "time", "tr", "u", "ul", "var", "wbr",
+)
+
+// Elements AMP replaces with one of its own components
+var replacedElements = map[string]string{
+	"img":    "amp-img",
+	"iframe": "amp-iframe",
+	"video":  "amp-video",
+	"audio":  "amp-audio",
+}
+
+// AMP components which are part of the runtime, so need no script of their own
+var builtinComponents = setOf("amp-img", "amp-pixel", "amp-layout")
+
+// AMP components which need a width and height to be laid out
+var sizedComponents = setOf("amp-img", "amp-anim", "amp-video", "amp-youtube", "amp-vimeo", "amp-iframe")
+
+// Sizes of components are given in whole pixels, such as 48 or 48px
+var pixelLength = regexp.MustCompile(`^\d+(px)?$`)
+
+// Layouts which do not need a width and height
+var unsizedLayouts = setOf("fill", "nodisplay", "container", "flex-item")
+
+type validator struct {
+	errors []string
+
+	hasRuntime        bool
+	hasCharset        bool
+	hasViewport       bool
+	hasCanonical      bool
+	hasBoilerplate    bool
+	hasNoscriptBoiler bool
+	customStyles      int
+	customCSS         int
+
+	// custom elements loaded by a script, and those used by the page
+	loaded map[string]bool
+	used   []string
+}
+
+// Validate gives one message per AMP violation found in the page
+func Validate(r io.Reader) ([]string, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &validator{loaded: make(map[string]bool)}
+	v.walk(doc, false)
+
+	if !v.hasCharset {
+		v.errorf(`missing <meta charset="utf-8">`)
+	}
+	if !v.hasViewport {
+		v.errorf(`missing <meta name="viewport">`)
+	}
+	if !v.hasRuntime {
+		v.errorf(`missing <script async src="%s">`, runtimeURL)
+	}
+	if !v.hasCanonical {
+		v.errorf(`missing <link rel="canonical">`)
+	}
+	if !v.hasBoilerplate || !v.hasNoscriptBoiler {
+		v.errorf("missing the amp-boilerplate styles")
+	}
+	if v.customCSS > MaxCustomCSS {
+		v.errorf("<style amp-custom> is %d bytes, over the limit of %d", v.customCSS, MaxCustomCSS)
+	}
+	for _, name := range v.used {
+		if !v.loaded[name] {
+			v.errorf(`<%s> is used without its <script custom-element="%s">`, name, name)
+			v.loaded[name] = true
+		}
+	}
+	return v.errors, nil
+}
+
+// ValidateFile validates the page built at the path
+func ValidateFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Validate(f)
+}
+
+func (v *validator) walk(n *html.Node, inHead bool) {
+	if n.Type == html.ElementNode {
+		if !v.element(n, inHead) {
+			return
+		}
+		inHead = inHead || n.Data == "head"
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		v.walk(c, inHead)
+	}
+}
+
+// Checks an element and its attributes, giving whether its children should
+// be checked as well.
+func (v *validator) element(n *html.Node, inHead bool) bool {
+	name := n.Data
+	v.attributes(n)
+
+	if strings.HasPrefix(name, "amp-") {
+		v.component(n)
+		return true
+	}
+	if replacement, ok := replacedElements[name]; ok {
+		v.errorf("<%s> is not allowed, use <%s>", name, replacement)
+		return true
+	}
+	if !allowedElements[name] {
+		v.errorf("<%s> is not allowed", name)
+		return true
+	}
+
+	switch name {
+	case "html":
+		if !hasAttr(n, "amp") && !hasAttr(n, "⚡") {
+			v.errorf("<html> is missing the amp attribute")
+		}
+
+	case "meta":
+		if hasAttr(n, "charset") {
+			v.hasCharset = strings.EqualFold(attr(n, "charset"), "utf-8")
+		}
+		if attr(n, "name") == "viewport" {
+			v.hasViewport = true
+		}
+
+	case "link":
+		switch strings.ToLower(attr(n, "rel")) {
+		case "canonical":
+			v.hasCanonical = true
+		case "stylesheet":
+			if !strings.HasPrefix(attr(n, "href"), fontPrefix) {
+				v.errorf("stylesheet %s is not allowed, only fonts may be linked", attr(n, "href"))
+			}
+		}
+
+	case "script":
+		v.script(n)
+		// the contents of a script are not html
+		return false
+
+	case "style":
+		v.style(n, inHead)
+		return false
+
+	case "noscript":
+		// noscript is parsed as text, as if scripting were enabled
+		if inHead && strings.Contains(text(n), "amp-boilerplate") {
+			v.hasNoscriptBoiler = true
+		}
+		return false
+
+	case "svg":
+		// svg has its own elements and attributes
+		return false
+	}
+	return true
+}
+
+func (v *validator) attributes(n *html.Node) {
+	for _, a := range n.Attr {
+		key := strings.ToLower(a.Key)
+		switch {
+		case key == "style":
+			v.errorf("<%s> has an inline style attribute", n.Data)
+		case strings.HasPrefix(key, "on") && key != "on":
+			v.errorf("<%s> has a %s event handler", n.Data, key)
+		case (key == "href" || key == "src") &&
+			strings.HasPrefix(strings.ToLower(strings.TrimSpace(a.Val)), "javascript:"):
+			v.errorf("<%s> has a javascript: url", n.Data)
+		}
+	}
+}
+
+func (v *validator) component(n *html.Node) {
+	name := n.Data
+	if !builtinComponents[name] {
+		v.used = append(v.used, name)
+	}
+	if sizedComponents[name] && !unsizedLayouts[attr(n, "layout")] {
+		width, height := attr(n, "width"), attr(n, "height")
+		if len(width) == 0 || len(height) == 0 {
+			v.errorf("<%s src=%q> is missing a width or height", name, attr(n, "src"))
+		} else if !pixelLength.MatchString(width) || !pixelLength.MatchString(height) {
+			v.errorf("<%s src=%q> has a width or height which is not a whole number of pixels", name, attr(n, "src"))
+		}
+	}
+}
+
+func (v *validator) script(n *html.Node) {
+	if attr(n, "type") == "application/ld+json" {
+		return
+	}
+	src := attr(n, "src")
+	if !strings.HasPrefix(src, scriptPrefix) || !hasAttr(n, "async") {
+		v.errorf("<script src=%q> is not allowed, only async AMP scripts are", src)
+		return
+	}
+	if src == runtimeURL {
+		v.hasRuntime = true
+	}
+	if element := attr(n, "custom-element"); len(element) != 0 {
+		v.loaded[element] = true
+	}
+}
+
+func (v *validator) style(n *html.Node, inHead bool) {
+	switch {
+	case !inHead:
+		v.errorf("<style> is only allowed in the head")
+	case hasAttr(n, "amp-boilerplate"):
+		v.hasBoilerplate = true
+	case hasAttr(n, "amp-custom"):
+		if v.customStyles++; v.customStyles > 1 {
+			v.errorf("only one <style amp-custom> is allowed")
+		}
+		css := text(n)
+		v.customCSS += len(css)
+		if strings.Contains(css, "!important") {
+			v.errorf("<style amp-custom> uses !important")
+		}
+	default:
+		v.errorf("<style> must be amp-custom or amp-boilerplate")
+	}
+}
+
+func (v *validator) errorf(format string, args ...interface{}) {
+	v.errors = append(v.errors, fmt.Sprintf(format, args...))
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func text(n *html.Node) string {
+	s := ""
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode {
+			s += c.Data
+		}
+	}
+	return s
+}
+
+func setOf(values ...string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
@@ -0,0 +1,89 @@
+package amp
+
+import (
+	"strings"
+	"testing"
+)
+
+// Wraps a body in the head every AMP page needs
+func page(head string, body string) string {
+	return `<!doctype html><html amp lang="en"><head>` +
+		`<meta charset="utf-8">` +
+		`<script async src="https://cdn.ampproject.org/v0.js"></script>` +
+		`<link rel="canonical" href="https://example.com/">` +
+		`<meta name="viewport" content="width=device-width">` +
+		`<style amp-boilerplate>body{}</style><noscript><style amp-boilerplate>body{}</style></noscript>` +
+		head + `</head><body>` + body + `</body></html>`
+}
+
+func validate(t *testing.T, doc string) []string {
+	errors, err := Validate(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return errors
+}
+
+func TestValidatePage(t *testing.T) {
+	doc := page(
+		`<script async custom-element="amp-youtube" src="https://cdn.ampproject.org/v0/amp-youtube-0.1.js"></script>`+
+			`<script type="application/ld+json">{}</script>`+
+			`<link href="https://fonts.googleapis.com/css?family=Roboto" rel="stylesheet">`+
+			`<style amp-custom>p { color: red; }</style>`,
+		`<p>Hi <amp-img src="a.png" width="10" height="10" layout="responsive"></amp-img></p>`+
+			`<amp-youtube data-videoid="x" layout="responsive" width="480" height="270"></amp-youtube>`+
+			`<button on="tap:sidebar.toggle">Menu</button>`)
+	if errors := validate(t, doc); len(errors) != 0 {
+		t.Fatalf("expected a valid page, got %v", errors)
+	}
+}
+
+func TestValidateMissingBoilerplate(t *testing.T) {
+	errors := validate(t, `<html><head><title>x</title></head><body></body></html>`)
+	if len(errors) != 6 {
+		t.Fatalf("expected 6 errors, got %v", errors)
+	}
+}
+
+func TestValidateDisallowedContent(t *testing.T) {
+	doc := page("",
+		`<img src="a.png">`+
+			`<iframe src="https://example.com"></iframe>`+
+			`<script src="https://example.com/x.js"></script>`+
+			`<font>x</font>`+
+			`<p style="color: red" onclick="x()">y</p>`+
+			`<a href="javascript:alert(1)">z</a>`+
+			`<amp-img src="b.png" layout="responsive"></amp-img>`+
+			`<amp-vimeo data-videoid="1" width="1" height="1"></amp-vimeo>`+
+			`<amp-img src="c.png" width="3em" height="3em"></amp-img>`)
+
+	want := []string{
+		"<img> is not allowed, use <amp-img>",
+		"<iframe> is not allowed, use <amp-iframe>",
+		`<script src="https://example.com/x.js"> is not allowed`,
+		"<font> is not allowed",
+		"<p> has an inline style attribute",
+		"<p> has a onclick event handler",
+		"<a> has a javascript: url",
+		`<amp-img src="b.png"> is missing a width or height`,
+		`<amp-img src="c.png"> has a width or height which is not a whole number of pixels`,
+		`<amp-vimeo> is used without its <script custom-element="amp-vimeo">`,
+	}
+	errors := validate(t, doc)
+	if len(errors) != len(want) {
+		t.Fatalf("expected %d errors, got %v", len(want), errors)
+	}
+	for i, w := range want {
+		if !strings.HasPrefix(errors[i], w) {
+			t.Fatalf("error %d is %q, want %q", i, errors[i], w)
+		}
+	}
+}
+
+func TestValidateCustomCSSLimit(t *testing.T) {
+	css := strings.Repeat("p{}", MaxCustomCSS/3+1)
+	errors := validate(t, page(`<style amp-custom>`+css+`</style>`, ""))
+	if len(errors) != 1 || !strings.Contains(errors[0], "over the limit") {
+		t.Fatalf("expected the css to be too large, got %v", errors)
+	}
+}
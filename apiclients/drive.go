@@ -1,6 +1,7 @@
 package apiclients
 import (
   "net/http"
+  "time"
   "golang.org/x/net/context"
   "google.golang.org/api/drive/v3"
 )
@@ -43,3 +44,32 @@ func GetGdocHtml(clientSecretPath string, gdocID string) (*http.Response, error)
 
   return resp, nil
 }
+
+// When a drive file, such as a gdoc or sheet, was created and last modified
+type FileTimes struct {
+  Created time.Time
+  Modified time.Time
+}
+
+func GetFileTimes(clientSecretPath string, fileID string) (*FileTimes, error) {
+  client, err := NewDriveFilesService(clientSecretPath)
+  if err != nil {
+    return nil, err
+  }
+
+  file, err := client.Get(fileID).Fields("createdTime", "modifiedTime").Do()
+  if err != nil {
+    return nil, err
+  }
+
+  created, err := time.Parse(time.RFC3339, file.CreatedTime)
+  if err != nil {
+    return nil, err
+  }
+  modified, err := time.Parse(time.RFC3339, file.ModifiedTime)
+  if err != nil {
+    return nil, err
+  }
+
+  return &FileTimes{Created: created, Modified: modified}, nil
+}
@@ -4,6 +4,7 @@ import (
   "fmt"
   "io/ioutil"
   "testing"
+  "time"
 )
 
 
@@ -24,3 +25,17 @@ func TestNewDriveFilesService(t *testing.T) {
       t.Fatal("No body from gdoc")
   }
 }
+
+func TestGetFileTimes(t *testing.T) {
+  times, err := GetFileTimes("../client_secret.json", "1AfHN84meZVkywhN59bNJsvCD1FbtO3Srbh5tJmG_tX8")
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  if times.Created.IsZero() || times.Modified.Before(times.Created) {
+    t.Fatalf("Bad file times: %v", times)
+  }
+  if times.Modified.After(time.Now()) {
+    t.Fatal("gdoc modified in the future")
+  }
+}
@@ -5,7 +5,9 @@ import (
   "os/exec"
   "os"
   "fmt"
+  "path/filepath"
   "github.com/spf13/cobra"
+  "github.com/cobookman/gcp-quickstart/amp"
   "github.com/cobookman/gcp-quickstart/layout"
   "github.com/cobookman/gcp-quickstart/renders"
   "github.com/fatih/color"
@@ -16,6 +18,16 @@ var clientSecretPath string
 var isClean bool
 var domain string
 var gaID string
+var reportPath string
+var failOnComments bool
+var strictAMP bool
+var strict bool
+var claatPath string
+var contentDir string
+
+// Pages are built here, and only replace build/ once every check passes, so
+// that a failing build never leaves a tree to publish
+const stagingFolder = "build.staging"
 
 var buildCmd = &cobra.Command{
 	Use: "build",
@@ -32,6 +44,12 @@ func init() {
   buildCmd.Flags().StringVarP(&clientSecretPath, "client_secret", "s", "client_secret.json", "path to the oauth client secret")
   buildCmd.Flags().StringVarP(&domain, "domain", "d", "https://example.com", "root domain url. used in cononical metadata")
   buildCmd.Flags().StringVarP(&gaID, "ga", "g", "", "Google Analytics ID")
+  buildCmd.Flags().StringVar(&reportPath, "report", "build-report.json", "path to write the build report to")
+  buildCmd.Flags().BoolVar(&failOnComments, "fail-on-comments", false, "Fail the build if any published source doc has open comments")
+  buildCmd.Flags().StringVar(&contentDir, "content", "content", "folder of the markdown lessons")
+  buildCmd.Flags().StringVar(&claatPath, "claat", "claat", "path to the claat binary, used to build claat lessons")
+  buildCmd.Flags().BoolVar(&strictAMP, "strict-amp", false, "Fail the build if any page is not valid AMP")
+  buildCmd.Flags().BoolVar(&strict, "strict", false, "Fail the build if any published source renders with warnings, such as an unclosed code fence")
 }
 
 func Build() {
@@ -39,9 +57,13 @@ func Build() {
     Clean()
   }
 
+  if err := os.RemoveAll(stagingFolder); err != nil {
+    log.Fatal(err)
+  }
+
   color.Red("Copying Statics")
-  os.MkdirAll("build/statics/", os.ModePerm)
-  if err := exec.Command("cp", "-r", "statics/", "build/").Run(); err != nil {
+  os.MkdirAll(stagingFolder + "/statics/", os.ModePerm)
+  if err := exec.Command("cp", "-r", "statics/", stagingFolder + "/").Run(); err != nil {
     log.Fatal(err)
   }
 
@@ -51,32 +73,204 @@ func Build() {
     log.Fatal(err)
   }
 
+  ctx := &renders.BuildContext{
+    Layout: layout,
+    BuildFolder: stagingFolder,
+    Domain: domain,
+    ClientSecretPath: clientSecretPath,
+    ContentDir: contentDir,
+    ClaatPath: claatPath,
+    GA: gaID,
+  }
+  if err := checkRenderers(ctx); err != nil {
+    log.Fatal(err)
+  }
+
   color.Red("Building Webpages")
+  lessons, err := buildLessons(ctx)
+  if err != nil {
+    log.Fatal(err)
+  }
+
+  others, err := buildOthers(ctx)
+  if err != nil {
+    log.Fatal(err)
+  }
+
+  // once every lesson is rendered, so that none links to a draft
+  if err := writeOutputs(append(lessons, others...)); err != nil {
+    log.Fatal(err)
+  }
+
+  // after the lessons, which fill in the details category pages show
   if err := buildCategories(layout); err != nil {
     log.Fatal(err)
   }
 
-  if err := buildLessons(layout); err != nil {
+  if err := buildProducts(layout); err != nil {
+    log.Fatal(err)
+  }
+
+  if err := buildHome(layout); err != nil {
+    log.Fatal(err)
+  }
+
+  // pages hosted elsewhere are never built, so are left out
+  color.Red("Writing Sitemap")
+  if err := renders.RenderSitemap(report.SitemapPages(), stagingFolder, domain); err != nil {
+    log.Fatal(err)
+  }
+  if err := renders.RenderRobots(stagingFolder, domain); err != nil {
     log.Fatal(err)
   }
 
-  if err := buildOthers(clientSecretPath, layout); err != nil {
+  color.Red("Writing Build Report")
+  if err := report.Write(reportPath); err != nil {
+    log.Fatal(err)
+  }
+
+  if failOnComments {
+    if pages := report.PagesWithComments(); len(pages) != 0 {
+      for _, page := range pages {
+        color.Red("\t%s has %d open comments in %s", page.Path, len(page.Comments), page.Source)
+      }
+      log.Fatal("Source docs have open comments, resolve them before publishing")
+    }
+  }
+
+  if strict {
+    if pages := report.PagesWithWarnings(); len(pages) != 0 {
+      for _, page := range pages {
+        color.Red("\t%s has %d warnings in %s", page.Path, len(page.Warnings), page.Source)
+      }
+      log.Fatal("Source docs render with warnings, fix them before publishing")
+    }
+  }
+
+  if strictAMP {
+    if pages := report.PagesWithAMPErrors(); len(pages) != 0 {
+      for _, page := range pages {
+        color.Red("\t%s has %d AMP errors", page.Path, len(page.AMPErrors))
+      }
+      log.Fatal("Pages are not valid AMP, fix them before publishing")
+    }
+  }
+
+  color.Red("Publishing Build")
+  if err := os.RemoveAll("build/"); err != nil {
+    log.Fatal(err)
+  }
+  if err := os.Rename(stagingFolder, "build"); err != nil {
     log.Fatal(err)
   }
 }
 
-func buildOthers(clientSecretPath string, layout *layout.Layout) error {
+// Renders the other pages, marking those which are drafts. Their pages are
+// left to writeOutputs.
+func buildOthers(ctx *renders.BuildContext) ([]*renders.Output, error) {
   color.Blue("Building Other Pages")
-  for _, other := range layout.Others {
+  var outs []*renders.Output
+  for _, other := range ctx.Layout.Others {
     color.Magenta("\tBuilding Other: " + other.URL)
-    gr , err := renders.RenderGdoc(layout, clientSecretPath, other.SourceGDoc, "build", other.URL, domain)
+
+    name, renderer := renders.RendererFor(other.Lesson())
+    if renderer == nil {
+      color.Red("\t\tPage has no source, skipping")
+      continue
+    }
+    fmt.Println("\t\tBuilding " + name)
+    out, err := renderer.Render(other.Lesson(), ctx)
     if err != nil {
+      return nil, err
+    }
+    other.Draft = out.Draft
+
+    fmt.Printf("\t\tTitle: %s\n", out.Title)
+    outs = append(outs, out)
+  }
+  return outs, nil
+}
+
+// Writes out what the renderers left to be written, other than drafts, and
+// reports on everything they built
+func writeOutputs(outs []*renders.Output) error {
+  color.Blue("Writing Pages")
+  for _, out := range outs {
+    color.Magenta("\tWriting: " + out.Source)
+    if out.Write != nil && !out.Draft {
+      if err := out.Write(); err != nil {
+        return err
+      }
+    }
+    if err := checkOutput(out); err != nil {
+      return err
+    }
+  }
+  return nil
+}
+
+// Checks a built page is valid AMP, recording its violations in the report
+func validateAMP(path string) error {
+  errors, err := amp.ValidateFile(filepath.Join(stagingFolder, path))
+  if err != nil {
+    return err
+  }
+  report.page(path).AMPErrors = errors
+  for _, e := range errors {
+    color.Yellow("\t\tAMP: %s", e)
+  }
+  return nil
+}
+
+// Reports what a renderer built, printing its problems and validating its
+// pages
+func checkOutput(out *renders.Output) error {
+  if out.Draft {
+    color.Yellow("\t\tDraft, not published")
+  }
+  reportOutput(out)
+  for _, warning := range out.Warnings {
+    color.Yellow("\t\tWarning: %s", warning)
+  }
+  for _, comment := range out.Comments {
+    color.Yellow("\t\tOpen comment on \"%s\": %s", comment.Context, comment.Text)
+  }
+  // drafts are not written out
+  if !out.AMP || out.Draft {
+    return nil
+  }
+  for _, page := range out.Pages {
+    if err := validateAMP(page); err != nil {
+      return err
+    }
+  }
+  return nil
+}
+
+func buildProducts(layout *layout.Layout) error {
+  color.Blue("Building Product Pages")
+  for _, product := range layout.Products {
+    color.Magenta("\tBuilding Product: " + product.Name)
+    if err := renders.RenderProduct(layout, product, stagingFolder, domain); err != nil {
+      return err
+    }
+    if err := validateAMP(product.Href()); err != nil {
       return err
     }
+    report.page(product.Href()).Updated = product.LastUpdated(layout)
+  }
+  return nil
+}
 
-    fmt.Printf("\t\tTitle: %s\n\t\tSummary: %s\n\t\tAuthor: %s\n\t\tImage: %s\n",
-      gr.Metadata.Title, gr.Metadata.Summary, gr.Metadata.Author, gr.Metadata.Image)
+func buildHome(layout *layout.Layout) error {
+  color.Blue("Building Home Page")
+  if err := renders.RenderHome(layout, stagingFolder, domain); err != nil {
+    return err
+  }
+  if err := validateAMP("/index.html"); err != nil {
+    return err
   }
+  report.page("/index.html").Updated = layout.LastUpdated()
   return nil
 }
 
@@ -84,33 +278,62 @@ func buildCategories(layout *layout.Layout) error {
   color.Blue("Building Category Pages")
   for _, category := range layout.Categories {
     color.Magenta("\tBuilding Category: " + category.Name)
-    if err := renders.RenderCategory(layout, category, "build", domain); err != nil {
+    if err := renders.RenderCategory(layout, category, stagingFolder, domain); err != nil {
       return err
     }
+    path := "/" + category.ID + "/index.html"
+    if err := validateAMP(path); err != nil {
+      return err
+    }
+    report.page(path).Updated = layout.Updated
     fmt.Println("\t\tBuilt")
   }
   return nil
 }
 
-func buildLessons(layout *layout.Layout) error {
+// Checks the tools of the renderers the layout's lessons need are installed
+func checkRenderers(ctx *renders.BuildContext) error {
+  checked := make(map[string]bool)
+  for _, lesson := range ctx.Layout.Lessons {
+    name, renderer := renders.RendererFor(lesson)
+    checker, ok := renderer.(renders.Checker)
+    if !ok || checked[name] {
+      continue
+    }
+    checked[name] = true
+    color.Red("Checking " + name)
+    version, err := checker.Check(ctx)
+    if err != nil {
+      return err
+    }
+    if version == renders.DevVersion {
+      color.Yellow("\t%s is an unreleased dev build, so its version is not checked", name)
+      continue
+    }
+    fmt.Printf("\t%s %s\n", name, version)
+  }
+  return nil
+}
+
+// Renders the lessons, which fills them in, drafts included. Their pages are
+// left to writeOutputs.
+func buildLessons(ctx *renders.BuildContext) ([]*renders.Output, error) {
   color.Blue("Building Lesson Pages")
-  for _, lesson := range layout.Lessons {
+  var outs []*renders.Output
+  for _, lesson := range ctx.Layout.Lessons {
     color.Magenta("\tBuilding lesson: " + lesson.Name)
 
-    if len(lesson.SourceClaat) != 0 {
-      color.Cyan("\t\tBuilding claat")
-      if err := renders.RenderClaat(lesson, gaID, "build"); err != nil {
-        return err
-      }
-    } else if len(lesson.SourceGDoc) != 0 {
-      fmt.Println("\t\tBuilding Gdoc")
-
-    } else if len(lesson.SourceURL) != 0 {
-      fmt.Println("\t\tUsing Source URL")
-
-    } else {
+    name, renderer := renders.RendererFor(lesson)
+    if renderer == nil {
       color.Red("\t\tLesson has no source, skipping")
+      continue
     }
+    fmt.Println("\t\tBuilding " + name)
+    out, err := renderer.Render(lesson, ctx)
+    if err != nil {
+      return nil, err
+    }
+    outs = append(outs, out)
   }
-  return nil
+  return outs, nil
 }
@@ -11,7 +11,7 @@ import (
 var cleanCmd = &cobra.Command{
 	Use: "clean",
 	Short: "Cleans all built assets",
-	Long: "Removes the build/ folder",
+	Long: "Removes the build/ folder, and that of any unfinished build",
 	Run: func(cmd *cobra.Command, args []string) {
     Clean()
   },
@@ -22,4 +22,7 @@ func Clean() {
   if err := os.RemoveAll("build/"); err != nil {
     log.Fatal(err)
   }
+  if err := os.RemoveAll(stagingFolder); err != nil {
+    log.Fatal(err)
+  }
 }
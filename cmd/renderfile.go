@@ -0,0 +1,72 @@
+package cmd
+
+import (
+  "log"
+  "fmt"
+  "path/filepath"
+  "strings"
+  "github.com/spf13/cobra"
+  "github.com/cobookman/gcp-quickstart/amp"
+  "github.com/cobookman/gcp-quickstart/layout"
+  "github.com/cobookman/gcp-quickstart/renders"
+  "github.com/fatih/color"
+)
+
+var previewFolder string
+
+var renderFileCmd = &cobra.Command{
+	Use: "render-file <export.html|export.zip|lesson.md>",
+	Short: "Render a locally exported gdoc or a markdown lesson to a preview page",
+	Long: "Renders a gdoc downloaded as a web page (.html or .zip), or a markdown lesson, into a single preview page, without signing in to google",
+	Run: func(cmd *cobra.Command, args []string) {
+    if len(args) != 1 {
+      log.Fatal("render-file takes the path of one exported gdoc or markdown file")
+    }
+    RenderFile(args[0])
+  },
+}
+
+func init() {
+  renderFileCmd.Flags().StringVarP(&previewFolder, "out", "o", "preview", "folder to write the preview page to")
+  renderFileCmd.Flags().StringVarP(&domain, "domain", "d", "https://example.com", "root domain url. used in cononical metadata")
+}
+
+func RenderFile(exportPath string) {
+  color.Red("Rendering " + exportPath)
+  // without a layout the page's header has no categories, and links to other
+  // gdocs or markdown files are left as they are
+  var gr *renders.GdocRender
+  var err error
+  if strings.ToLower(filepath.Ext(exportPath)) == ".md" {
+    gr, err = renders.PreviewMarkdown(new(layout.Layout), exportPath, previewFolder, "/index.html", domain)
+  } else {
+    gr, err = renders.PreviewGdoc(new(layout.Layout), exportPath, previewFolder, "/index.html", domain)
+  }
+  if err != nil {
+    log.Fatal(err)
+  }
+  if gr.IsDraft() {
+    color.Yellow("\tDraft, which build does not publish")
+  }
+  if gr.Metadata.Steps != renders.StepsNone {
+    color.Yellow("\tSplit at %s when built, previewed as one page", gr.Metadata.Steps)
+  }
+
+  fmt.Printf("\tTitle: %s\n\tSummary: %s\n\tAuthor: %s\n", gr.Metadata.Title, gr.Metadata.Summary, gr.Metadata.Author)
+  for _, warning := range gr.Warnings {
+    color.Yellow("\tWarning: %s", warning)
+  }
+  for _, comment := range gr.Comments {
+    color.Yellow("\tOpen comment on \"%s\": %s", comment.Context, comment.Text)
+  }
+  for _, page := range gr.Pages() {
+    errors, err := amp.ValidateFile(filepath.Join(previewFolder, page))
+    if err != nil {
+      log.Fatal(err)
+    }
+    for _, e := range errors {
+      color.Yellow("\tAMP: %s", e)
+    }
+    fmt.Println("\tWrote " + filepath.Join(previewFolder, page))
+  }
+}
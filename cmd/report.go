@@ -0,0 +1,117 @@
+package cmd
+
+import (
+  "encoding/json"
+  "os"
+  "time"
+  "github.com/cobookman/gcp-quickstart/renders"
+)
+
+// Summary of a build, written out next to the build folder so that it is not
+// uploaded along with the site.
+type BuildReport struct {
+  Built time.Time
+  Pages []*PageReport
+}
+
+type PageReport struct {
+  Path string
+  Source string
+  Warnings []string
+  Comments []*renders.GdocComment
+  AMPErrors []string
+  // Drafts are reported for their problems, but are not published
+  Draft bool
+  // when the page's source was last updated, if it is known
+  Updated time.Time
+}
+
+var report = new(BuildReport)
+
+// Adds a rendered source to the build report, under the path of its first
+// page
+func reportOutput(out *renders.Output) *PageReport {
+  if len(out.Pages) == 0 {
+    return nil
+  }
+  for _, path := range out.Pages {
+    page := report.page(path)
+    page.Updated = out.Updated
+    page.Draft = out.Draft
+  }
+  page := report.page(out.Pages[0])
+  page.Source = out.Source
+  page.Warnings = out.Warnings
+  page.Comments = out.Comments
+  return page
+}
+
+// Finds the report of the page at path, adding one if there is none yet
+func (r *BuildReport) page(path string) *PageReport {
+  for _, page := range r.Pages {
+    if page.Path == path {
+      return page
+    }
+  }
+  page := &PageReport{Path: path}
+  r.Pages = append(r.Pages, page)
+  return page
+}
+
+// Published pages which still have open comment threads
+func (r *BuildReport) PagesWithComments() []*PageReport {
+  var pages []*PageReport
+  for _, page := range r.Pages {
+    if len(page.Comments) != 0 && !page.Draft {
+      pages = append(pages, page)
+    }
+  }
+  return pages
+}
+
+// Published pages whose sources rendered with warnings
+func (r *BuildReport) PagesWithWarnings() []*PageReport {
+  var pages []*PageReport
+  for _, page := range r.Pages {
+    if len(page.Warnings) != 0 && !page.Draft {
+      pages = append(pages, page)
+    }
+  }
+  return pages
+}
+
+// Pages which are not valid AMP
+func (r *BuildReport) PagesWithAMPErrors() []*PageReport {
+  var pages []*PageReport
+  for _, page := range r.Pages {
+    if len(page.AMPErrors) != 0 {
+      pages = append(pages, page)
+    }
+  }
+  return pages
+}
+
+// Every published page, for the sitemap to list
+func (r *BuildReport) SitemapPages() []*renders.SitemapPage {
+  var pages []*renders.SitemapPage
+  for _, page := range r.Pages {
+    if page.Draft {
+      continue
+    }
+    pages = append(pages, &renders.SitemapPage{Path: page.Path, Updated: page.Updated})
+  }
+  return pages
+}
+
+func (r *BuildReport) Write(path string) error {
+  r.Built = time.Now()
+  f, err := os.Create(path)
+  if err != nil {
+    return err
+  }
+  defer f.Close()
+
+  enc := json.NewEncoder(f)
+  enc.SetIndent("", "  ")
+  return enc.Encode(r)
+}
@@ -33,6 +33,7 @@ func init() {
 	RootCmd.AddCommand(cleanCmd)
 	RootCmd.AddCommand(buildCmd)
 	RootCmd.AddCommand(uploadCmd)
+	RootCmd.AddCommand(renderFileCmd)
 
 }
 
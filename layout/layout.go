@@ -2,8 +2,10 @@ package layout
 
 import (
   "log"
+  "sort"
   "strings"
   "fmt"
+  "time"
   "github.com/cobookman/gcp-quickstart/apiclients"
 )
 
@@ -14,7 +16,26 @@ type Lesson struct {
   SourceURL string
   SourceClaat string
   SourceGDoc string
+  // Path of a markdown file within the content folder
+  SourceMarkdown string
+  // Sources of types the layout has no column of their own for, keyed by the
+  // name their renderer is registered under
+  Sources map[string]string
   Href string
+  // Featured lessons are shown on the home page
+  Featured bool
+  // Drafts are not published, nor listed or linked to on the site. Found
+  // from the lesson's source before any lesson is built.
+  Draft bool
+  // Filled in from the lesson's source once it is built
+  Author string
+  Duration time.Duration
+  Updated time.Time
+}
+
+// Duration of the lesson in whole minutes, rounded up
+func (l *Lesson) Minutes() int {
+  return int((l.Duration + time.Minute - 1) / time.Minute)
 }
 
 type Product struct {
@@ -24,9 +45,38 @@ type Product struct {
   Acronym string
   Summary string
   Icon string
+  // Url of the product's official documentation, if the layout gives one
+  Docs string
   Lessons []*Lesson
 }
 
+// Path of the product's landing page, which its lessons are under
+func (p *Product) Href() string {
+  return "/" + p.Category.ID + "/" + p.ID + "/index.html"
+}
+
+// The product's lessons which are not drafts
+func (p *Product) PublishedLessons() []*Lesson {
+  var lessons []*Lesson
+  for _, lesson := range p.Lessons {
+    if !lesson.Draft {
+      lessons = append(lessons, lesson)
+    }
+  }
+  return lessons
+}
+
+// When the layout or any of the product's lessons was last updated
+func (p *Product) LastUpdated(l *Layout) time.Time {
+  updated := l.Updated
+  for _, lesson := range p.Lessons {
+    if lesson.Updated.After(updated) {
+      updated = lesson.Updated
+    }
+  }
+  return updated
+}
+
 type Category struct {
   ID string
   Name string
@@ -38,6 +88,14 @@ type Category struct {
 type Other struct {
   URL string
   SourceGDoc string
+  // Drafts are not published, nor linked to from other pages
+  Draft bool
+}
+
+// The page as a lesson without a product, so that it is built by the same
+// renderers as lessons are
+func (o *Other) Lesson() *Lesson {
+  return &Lesson{Name: o.URL, SourceGDoc: o.SourceGDoc, Href: o.URL, Draft: o.Draft}
 }
 
 type Layout struct {
@@ -45,6 +103,48 @@ type Layout struct {
   Products []*Product
   Lessons []*Lesson
   Others []*Other
+  // when the layout sheet was last modified
+  Updated time.Time
+}
+
+// Lessons marked as featured in the layout sheet
+func (l *Layout) FeaturedLessons() []*Lesson {
+  var lessons []*Lesson
+  for _, lesson := range l.Lessons {
+    if lesson.Featured && !lesson.Draft {
+      lessons = append(lessons, lesson)
+    }
+  }
+  return lessons
+}
+
+// The n most recently updated lessons. Lessons only know when they were
+// updated once they are built, so lessons hosted elsewhere are left out.
+func (l *Layout) RecentLessons(n int) []*Lesson {
+  var lessons []*Lesson
+  for _, lesson := range l.Lessons {
+    if !lesson.Updated.IsZero() && !lesson.Draft {
+      lessons = append(lessons, lesson)
+    }
+  }
+  sort.SliceStable(lessons, func(i, j int) bool {
+    return lessons[i].Updated.After(lessons[j].Updated)
+  })
+  if len(lessons) > n {
+    lessons = lessons[:n]
+  }
+  return lessons
+}
+
+// When the layout or any of its lessons was last updated
+func (l *Layout) LastUpdated() time.Time {
+  updated := l.Updated
+  for _, lesson := range l.Lessons {
+    if lesson.Updated.After(updated) {
+      updated = lesson.Updated
+    }
+  }
+  return updated
 }
 
 func GetLayout(clientSecretPath string, spreadsheetId string) (*Layout, error) {
@@ -55,6 +155,12 @@ func GetLayout(clientSecretPath string, spreadsheetId string) (*Layout, error) {
   }
   layout := new(Layout)
 
+  sheetTimes, err := apiclients.GetFileTimes(clientSecretPath, spreadsheetId)
+  if err != nil {
+    return nil, err
+  }
+  layout.Updated = sheetTimes.Modified
+
   // keep a reference to the tree structure
   categories := make(map[string]*Category)
   products := make(map[string]*Product)
@@ -85,7 +191,7 @@ func GetLayout(clientSecretPath string, spreadsheetId string) (*Layout, error) {
   }
 
   // Populate products
-  productColumns := "Products!A2:F"
+  productColumns := "Products!A2:G"
   productResp, err := srv.Spreadsheets.Values.Get(spreadsheetId, productColumns).Do()
   if err != nil {
     return nil, err
@@ -102,6 +208,10 @@ func GetLayout(clientSecretPath string, spreadsheetId string) (*Layout, error) {
       Summary: row[4].(string),
       Icon: row[5].(string),
     }
+    // the docs column is missing from rows which leave it empty
+    if len(row) > 6 {
+      product.Docs = row[6].(string)
+    }
 
     // Attach Product's category
     productCategory := categories[row[0].(string)]
@@ -115,7 +225,7 @@ func GetLayout(clientSecretPath string, spreadsheetId string) (*Layout, error) {
     products[product.ID] = product
   }
 
-  lessonColumns := "Lessons!A2:F"
+  lessonColumns := "Lessons!A2:J"
   lessonResp, err := srv.Spreadsheets.Values.Get(spreadsheetId, lessonColumns).Do()
   if err != nil {
     return nil, err
@@ -131,6 +241,17 @@ func GetLayout(clientSecretPath string, spreadsheetId string) (*Layout, error) {
       SourceClaat: row[4].(string),
       SourceGDoc: row[5].(string),
     }
+    // the markdown, featured and source type columns are missing from rows
+    // which leave them empty
+    if len(row) > 6 {
+      lesson.SourceMarkdown = row[6].(string)
+    }
+    if len(row) > 7 && strings.ToUpper(row[7].(string)) == "TRUE" {
+      lesson.Featured = true
+    }
+    if len(row) > 9 && len(row[8].(string)) != 0 {
+      lesson.Sources = map[string]string{row[8].(string): row[9].(string)}
+    }
 
     // Attach lesson's product
     lessonProduct := products[row[0].(string)]
@@ -139,7 +260,7 @@ func GetLayout(clientSecretPath string, spreadsheetId string) (*Layout, error) {
     // populate lesson's href
     if len(lesson.SourceURL) != 0 {
       lesson.Href = lesson.SourceURL
-    } else if len(lesson.SourceClaat) != 0 || len(lesson.SourceGDoc) != 0 {
+    } else if len(lesson.SourceClaat) != 0 || len(lesson.SourceGDoc) != 0 || len(lesson.SourceMarkdown) != 0 || len(lesson.Sources) != 0 {
       lesson.Href = fmt.Sprintf("/%s/%s/%s/index.html",
       lesson.Product.Category.ID, lesson.Product.ID, lesson.Name)
     }
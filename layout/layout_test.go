@@ -2,6 +2,7 @@ package layout
 
 import (
   "testing"
+  "time"
 )
 
 
@@ -27,3 +28,63 @@ func TestGetLayout(t *testing.T) {
     t.Fatal("No Others")
   }
 }
+
+func TestFeaturedAndRecentLessons(t *testing.T) {
+  day := func(d int) time.Time {
+    return time.Date(2017, 1, d, 0, 0, 0, 0, time.UTC)
+  }
+  layout := &Layout{Lessons: []*Lesson{
+    &Lesson{Name: "old", Updated: day(1), Featured: true},
+    &Lesson{Name: "external"},
+    &Lesson{Name: "newest", Updated: day(3)},
+    &Lesson{Name: "newer", Updated: day(2), Featured: true},
+  }}
+
+  featured := layout.FeaturedLessons()
+  if len(featured) != 2 || featured[0].Name != "old" || featured[1].Name != "newer" {
+    t.Fatalf("unexpected featured lessons %v", featured)
+  }
+
+  recent := layout.RecentLessons(2)
+  if len(recent) != 2 || recent[0].Name != "newest" || recent[1].Name != "newer" {
+    t.Fatalf("unexpected recent lessons %v", recent)
+  }
+  if len(layout.RecentLessons(10)) != 3 {
+    t.Fatal("expected lessons without an update time to be left out")
+  }
+}
+
+func TestProductHref(t *testing.T) {
+  product := &Product{ID: "gce", Category: &Category{ID: "compute"}}
+  if href := product.Href(); href != "/compute/gce/index.html" {
+    t.Fatalf("unexpected product href %s", href)
+  }
+}
+
+func TestLastUpdated(t *testing.T) {
+  sheet := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+  lesson := &Lesson{Updated: time.Date(2017, 2, 1, 0, 0, 0, 0, time.UTC)}
+  product := &Product{Lessons: []*Lesson{lesson}}
+  layout := &Layout{Updated: sheet, Lessons: []*Lesson{lesson}}
+
+  if !product.LastUpdated(layout).Equal(lesson.Updated) || !layout.LastUpdated().Equal(lesson.Updated) {
+    t.Fatal("expected the lesson's update time")
+  }
+  if empty := (&Product{}); !empty.LastUpdated(layout).Equal(sheet) {
+    t.Fatal("expected a product without lessons to fall back to the sheet's update time")
+  }
+}
+
+func TestDraftsAreNotListed(t *testing.T) {
+  draft := &Lesson{Name: "draft", Featured: true, Draft: true, Updated: time.Date(2017, 1, 2, 0, 0, 0, 0, time.UTC)}
+  published := &Lesson{Name: "published"}
+  product := &Product{Lessons: []*Lesson{draft, published}}
+  layout := &Layout{Lessons: product.Lessons}
+
+  if lessons := product.PublishedLessons(); len(lessons) != 1 || lessons[0] != published {
+    t.Fatalf("unexpected published lessons %v", lessons)
+  }
+  if len(layout.FeaturedLessons()) != 0 || len(layout.RecentLessons(5)) != 0 {
+    t.Fatal("expected drafts to be left off the home page")
+  }
+}
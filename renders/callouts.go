@@ -0,0 +1,140 @@
+package renders
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Labels which turn a single cell table into a callout, keyed by their kind
+var calloutLabels = map[string]string{
+	"note":    "Note",
+	"tip":     "Tip",
+	"warning": "Warning",
+	"caution": "Caution",
+}
+
+// block elements which can be placed in a callout without a wrapping <p>
+var blockAtoms = map[atom.Atom]bool{
+	atom.P: true, atom.Ul: true, atom.Ol: true, atom.Pre: true, atom.Div: true,
+	atom.Table: true, atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true,
+	atom.H5: true, atom.H6: true,
+}
+
+// Gives the kind of callout a single cell table is, going by the label its
+// text starts with, such as "Warning:".
+func calloutKind(table *html.Node) (string, bool) {
+	if !isSingleCellTable(table) {
+		return "", false
+	}
+	text := strings.TrimLeftFunc(codeText(table), unicode.IsSpace)
+	i := strings.Index(text, ":")
+	if i < 0 {
+		return "", false
+	}
+	kind := strings.ToLower(text[:i])
+	_, ok := calloutLabels[kind]
+	return kind, ok
+}
+
+// Replaces a callout table with an <aside class="callout callout-kind">
+// holding the table's content, without the label the author typed.
+func (gr *GdocRender) cleanAtomCallout(n *html.Node, kind string) (*html.Node, error) {
+	aside := &html.Node{
+		Type:     html.ElementNode,
+		DataAtom: atom.Aside,
+		Data:     "aside",
+		Attr:     []html.Attribute{{Key: "class", Val: "callout callout-" + kind}},
+	}
+	title := &html.Node{
+		Type:     html.ElementNode,
+		DataAtom: atom.Div,
+		Data:     "div",
+		Attr:     []html.Attribute{{Key: "class", Val: "callout-title"}},
+	}
+	title.AppendChild(&html.Node{Type: html.TextNode, Data: calloutLabels[kind]})
+	aside.AppendChild(title)
+
+	cell := findAll(n, atom.Td)[0]
+	removeCalloutLabel(cell)
+
+	content := aside
+	isInline := true
+	for c := cell.FirstChild; c != nil; c = c.NextSibling {
+		if blockAtoms[c.DataAtom] {
+			isInline = false
+		}
+	}
+	if isInline {
+		content = &html.Node{Type: html.ElementNode, DataAtom: atom.P, Data: "p"}
+		aside.AppendChild(content)
+	}
+	for c := cell.FirstChild; c != nil; c = cell.FirstChild {
+		cell.RemoveChild(c)
+		content.AppendChild(c)
+	}
+
+	if n.Parent != nil {
+		n.Parent.InsertBefore(aside, n)
+		n.Parent.RemoveChild(n)
+	}
+	return aside, nil
+}
+
+// Removes the "Kind:" label from the start of the callout's text, which may
+// be split across text nodes such as <b>Note</b>: text. Elements left empty,
+// such as the paragraph holding a label written on a line of its own, are
+// removed along with it.
+func removeCalloutLabel(cell *html.Node) {
+	var emptied []*html.Node
+	found := false
+	walkText(cell, func(t *html.Node) {
+		if found {
+			return
+		}
+		i := strings.Index(t.Data, ":")
+		if i < 0 {
+			t.Data = ""
+		} else {
+			t.Data = t.Data[i+1:]
+			found = true
+		}
+		emptied = append(emptied, t)
+	})
+	// trim the space after the colon, up to the callout's text
+	trimmed := false
+	walkText(cell, func(t *html.Node) {
+		if !trimmed {
+			t.Data = strings.TrimLeftFunc(t.Data, unicode.IsSpace)
+			trimmed = len(t.Data) != 0
+		}
+	})
+
+	for _, t := range emptied {
+		if len(t.Data) != 0 || !isInside(t, cell) {
+			continue
+		}
+		n := t
+		for n.Parent != cell && isBlank(n.Parent) {
+			n = n.Parent
+		}
+		n.Parent.RemoveChild(n)
+	}
+}
+
+// Whether an element has no text or images left in it
+func isBlank(n *html.Node) bool {
+	return len(strings.TrimSpace(codeText(n))) == 0 && len(findAll(n, atom.Img)) == 0
+}
+
+// Whether n is still under root, and was not removed along with an ancestor
+func isInside(n *html.Node, root *html.Node) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p == root {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package renders
+
+import (
+	"testing"
+
+	"golang.org/x/net/html/atom"
+)
+
+func TestCalloutKind(t *testing.T) {
+	for fragment, want := range map[string]string{
+		`<table><tr><td><p>Warning: careful</p></td></tr></table>`:          "warning",
+		`<table><tr><td><p> <b>TIP</b>: shorter</p></td></tr></table>`:      "tip",
+		`<table><tr><td><p>Reminder: not a callout</p></td></tr></table>`:   "",
+		`<table><tr><td><p>Note text without a label</p></td></tr></table>`: "",
+		`<table><tr><td>Note: a</td><td>b</td></tr></table>`:                "",
+	} {
+		kind, ok := calloutKind(parseBody(t, fragment).FirstChild)
+		if ok != (len(want) != 0) || ok && kind != want {
+			t.Fatalf("calloutKind(%s) = %s %v, want %s", fragment, kind, ok, want)
+		}
+	}
+}
+
+func TestCleanAtomCallout(t *testing.T) {
+	body := parseBody(t,
+		`<table><tr><td><p><span>Warning: this deletes </span><span>your instance</span></p></td></tr></table>`+
+			`<table><tr><td><span>Tip: inline</span></td></tr></table>`)
+	gr := new(GdocRender)
+	for _, table := range findAll(body, atom.Table) {
+		kind, _ := calloutKind(table)
+		if _, err := gr.cleanAtomCallout(table, kind); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := `<aside class="callout callout-warning"><div class="callout-title">Warning</div><p><span>this deletes </span><span>your instance</span></p></aside>` +
+		`<aside class="callout callout-tip"><div class="callout-title">Tip</div><p><span>inline</span></p></aside>`
+	if got := renderChildren(t, body); got != want {
+		t.Fatalf("got %s\nwant %s", got, want)
+	}
+}
+
+func TestRemoveCalloutLabel(t *testing.T) {
+	for fragment, want := range map[string]string{
+		`<p><b>Note</b>: keep <span>it short</span></p>`:        `<p>keep <span>it short</span></p>`,
+		`<p><span>Caution</span><span>:</span></p><p>Slow</p>`:  `<p>Slow</p>`,
+		`<p><span>Tip:</span></p><p>Use gcloud</p><p>twice</p>`: `<p>Use gcloud</p><p>twice</p>`,
+	} {
+		cell := findAll(parseBody(t, "<table><tr><td>"+fragment+"</td></tr></table>"), atom.Td)[0]
+		removeCalloutLabel(cell)
+		if got := renderChildren(t, cell); got != want {
+			t.Fatalf("removeCalloutLabel(%s) = %s, want %s", fragment, got, want)
+		}
+	}
+}
@@ -1,8 +1,6 @@
 package renders
 
 import (
-  "time"
-  "fmt"
   "bytes"
   "github.com/cobookman/gcp-quickstart/templates"
   "github.com/cobookman/gcp-quickstart/layout"
@@ -15,16 +13,22 @@ func RenderCategory(layout *layout.Layout, category *layout.Category, buildFolde
 
   pg := &templates.PageMetadata{
     Title: "GCP Quickstarts - " + category.Name,
+    Description: category.Summary,
+    Type: templates.TypeWebsite,
     FilePath: "/" + category.ID + "/index.html",
     Domain: domain,
+    Updated: layout.Updated,
     ArticleHTML: templates.RenderHTML(categoryHtml),
-    Social: &templates.Social{
-      Headline: fmt.Sprintf("Getting started with Google Cloud's %s offerings", category.Name),
-      DatePublished: time.Now(),
-      Image: []string{},
-    },
     Layout: layout,
   }
+  collection := templates.NewCollectionPage(pg)
+  if !pg.Updated.IsZero() {
+    collection.DateModified = &pg.Updated
+  }
+  pg.StructuredData = []templates.StructuredData{
+    collection,
+    templates.CategoryBreadcrumbs(domain, category),
+  }
 
   return templates.RenderPage(pg, buildFolder)
 }
@@ -2,62 +2,289 @@ package renders
 
 import (
   "os"
+  "bytes"
+  "encoding/json"
   "errors"
+  "fmt"
+  "io"
+  "io/ioutil"
+  "regexp"
+  "strconv"
+  "time"
   "path/filepath"
+  "github.com/PuerkitoBio/goquery"
   "github.com/cobookman/gcp-quickstart/layout"
+  "github.com/cobookman/gcp-quickstart/templates"
   "strings"
   "os/exec"
 )
 
-// Builds a claat source
-func RenderClaat(lesson *layout.Lesson, ga string, buildFolder string) error {
+// Metadata claat writes to codelab.json next to the codelab
+type ClaatCodelab struct {
+  ID string `json:"id"`
+  Title string `json:"title"`
+  Summary string `json:"summary"`
+  Author string `json:"author"`
+  Categories []string `json:"category"`
+  // In minutes
+  Duration int `json:"duration"`
+  Updated *time.Time `json:"updated"`
+}
+
+type ClaatRender struct {
+  Codelab *ClaatCodelab
+  Warnings []string
+}
+
+// What the claat templates are executed with
+type claatPage struct {
+  *templates.PageMetadata
+  Lesson *layout.Lesson
+}
+
+// Oldest claat version CheckClaat accepts. It is not tied to a feature
+// RenderClaat is known to need, and should be raised when one is.
+const MinClaatVersion = "1.0.0"
+
+var claatVersionPattern = regexp.MustCompile(`(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// Finds the claat binary and checks it is recent enough, giving its version
+func CheckClaat(claatPath string) (string, error) {
+  binary, err := exec.LookPath(claatPath)
+  if err != nil {
+    return "", errors.New("claat not found at " + claatPath + ", install it from https://github.com/googlecodelabs/tools or pass --claat")
+  }
+
+  out, err := exec.Command(binary, "version").CombinedOutput()
+  if err != nil {
+    return "", fmt.Errorf("%s version: %v: %s", binary, err, strings.TrimSpace(string(out)))
+  }
+  // claat built from source or installed with go get has no version to check
+  if strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(string(out)), "claat version")) == DevVersion {
+    return DevVersion, nil
+  }
+  version := claatVersionPattern.FindString(string(out))
+  if len(version) == 0 {
+    return "", errors.New("could not read the claat version from: " + strings.TrimSpace(string(out)))
+  }
+  if compareVersions(version, MinClaatVersion) < 0 {
+    return version, errors.New("claat " + version + " is too old, " + MinClaatVersion + " or newer is needed")
+  }
+  return version, nil
+}
+
+// Compares dotted versions such as 1.2.0, giving -1, 0 or 1
+func compareVersions(a string, b string) int {
+  partsA := claatVersionPattern.FindStringSubmatch(a)
+  partsB := claatVersionPattern.FindStringSubmatch(b)
+  for i := 1; i < 4; i++ {
+    var x, y int
+    if partsA != nil {
+      x, _ = strconv.Atoi(partsA[i])
+    }
+    if partsB != nil {
+      y, _ = strconv.Atoi(partsB[i])
+    }
+    if x != y {
+      if x < y {
+        return -1
+      }
+      return 1
+    }
+  }
+  return 0
+}
+
+// Builds a claat source, filling in the lesson from the codelab's metadata
+func RenderClaat(lesson *layout.Lesson, claatPath string, ga string, buildFolder string, domain string) (*ClaatRender, error) {
   if len(lesson.SourceClaat) == 0 {
-    return errors.New("No claat source given")
+    return nil, errors.New("No claat source given")
   }
   // Where we are building the claat
   buildPath := strings.Replace(lesson.Href, "index.html", "", 1)
 
-  // Create a temporary scratch location
-	scratchFolder := filepath.Join("scratch/",  buildPath)
+  // Export into a scratch folder of the lesson's own, so that lessons can be
+  // built side by side
+  scratchFolder, err := ioutil.TempDir("", "claat-")
+  if err != nil {
+    return nil, err
+  }
+  defer os.RemoveAll(scratchFolder)
 
-	os.RemoveAll(scratchFolder)
-	os.Mkdir(scratchFolder, os.ModePerm)
+  // Render Claat
+  claatCmd := exec.Command(claatPath,
+    "export",
+    "-prefix", "/",
+    "-f", "html",
+    "-ga", ga,
+    "-o", scratchFolder,
+    lesson.SourceClaat)
+  if err := runCommand(claatCmd); err != nil {
+    return nil, err
+  }
 
-	defer func() {
-		os.RemoveAll(scratchFolder)
-	}()
+  claatNames, err := filepath.Glob(filepath.Join(scratchFolder, "*"))
+  if err != nil {
+    return nil, err
+  }
+  if len(claatNames) != 1 {
+    return nil, fmt.Errorf("claat exported %d codelabs from %s, expected 1", len(claatNames), lesson.SourceClaat)
+  }
 
-	// Render Claat
-	claatCmd := exec.Command("claat",
-		"export",
-    "-prefix", "/",
-		"-f", "html",
-		"-ga", ga,
-		"-o", scratchFolder,
-		lesson.SourceClaat)
-
-	claatCmd.Stdout = os.Stdout
-	claatCmd.Stderr = os.Stderr
-	if err := claatCmd.Start(); err != nil {
-		return err
-	}
-	if err := claatCmd.Wait(); err != nil {
-		return err
-	}
-
-	if err := os.MkdirAll(filepath.Join(buildFolder, buildPath), os.ModePerm); err != nil {
-		return err
-	}
-	claatName, _ := filepath.Glob(scratchFolder + "/*")
-	copyCmd := exec.Command("cp", "-R", string(claatName[0]) + "/", filepath.Join(buildFolder, buildPath))
-	copyCmd.Stdout = os.Stdout
-	copyCmd.Stderr = os.Stderr
-	if err := copyCmd.Start(); err != nil {
-		return err
-	}
-	if err := copyCmd.Wait(); err != nil {
-		return err
-	}
-
-	return nil
+  // claat exports the codelab into a folder named after its id, which the
+  // lesson's folder takes the place of
+  if err := copyDir(claatNames[0], filepath.Join(buildFolder, buildPath)); err != nil {
+    return nil, err
+  }
+
+  codelab, err := readCodelab(filepath.Join(claatNames[0], "codelab.json"))
+  if err != nil {
+    return nil, err
+  }
+  cr := &ClaatRender{Codelab: codelab}
+  cr.apply(lesson)
+
+  return cr, wrapClaat(lesson, buildFolder, domain)
+}
+
+// Copies the contents of the src folder into dst, creating dst if needed
+func copyDir(src string, dst string) error {
+  return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+    if err != nil {
+      return err
+    }
+    rel, err := filepath.Rel(src, path)
+    if err != nil {
+      return err
+    }
+    target := filepath.Join(dst, rel)
+    if info.IsDir() {
+      return os.MkdirAll(target, os.ModePerm)
+    }
+    return copyFile(path, target, info.Mode())
+  })
+}
+
+func copyFile(src string, dst string, mode os.FileMode) error {
+  in, err := os.Open(src)
+  if err != nil {
+    return err
+  }
+  defer in.Close()
+
+  out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+  if err != nil {
+    return err
+  }
+  if _, err := io.Copy(out, in); err != nil {
+    out.Close()
+    return err
+  }
+  return out.Close()
+}
+
+// Runs a command, giving its stderr along with the error if it fails
+func runCommand(cmd *exec.Cmd) error {
+  stderr := new(bytes.Buffer)
+  cmd.Stdout = os.Stdout
+  cmd.Stderr = stderr
+  if err := cmd.Run(); err != nil {
+    return fmt.Errorf("%s: %v: %s", strings.Join(cmd.Args, " "), err, strings.TrimSpace(stderr.String()))
+  }
+  return nil
+}
+
+func readCodelab(path string) (*ClaatCodelab, error) {
+  b, err := ioutil.ReadFile(path)
+  if err != nil {
+    return nil, err
+  }
+  codelab := new(ClaatCodelab)
+  if err := json.Unmarshal(b, codelab); err != nil {
+    return nil, err
+  }
+  return codelab, nil
+}
+
+// Fills in the lesson from the codelab, warning when the sheet's lesson name
+// no longer matches the codelab's title.
+func (cr *ClaatRender) apply(lesson *layout.Lesson) {
+  codelab := cr.Codelab
+  if len(codelab.Summary) != 0 && len(lesson.Summary) == 0 {
+    lesson.Summary = codelab.Summary
+  }
+  lesson.Author = codelab.Author
+  lesson.Duration = time.Duration(codelab.Duration) * time.Minute
+  if codelab.Updated != nil {
+    lesson.Updated = *codelab.Updated
+  }
+
+  name, title := slugify(lesson.Name), slugify(codelab.Title)
+  if !strings.Contains(title, name) && !strings.Contains(name, title) {
+    cr.Warnings = append(cr.Warnings, "lesson name \"" + lesson.Name + "\" does not match the codelab's title \"" + codelab.Title + "\"")
+  }
+}
+
+// Gives claat's standalone page the site's canonical url and structured data,
+// along with a bar linking back to the lesson's category and product.
+func wrapClaat(lesson *layout.Lesson, buildFolder string, domain string) error {
+  pagePath := filepath.Join(buildFolder, lesson.Href)
+  f, err := os.Open(pagePath)
+  if err != nil {
+    return err
+  }
+  doc, err := goquery.NewDocumentFromReader(f)
+  f.Close()
+  if err != nil {
+    return err
+  }
+
+  title := strings.TrimSpace(doc.Find("title").Text())
+  if len(title) == 0 {
+    title = lesson.Name
+  }
+  pg := &templates.PageMetadata{
+    Title: title,
+    Description: lesson.Summary,
+    Type: templates.TypeArticle,
+    FilePath: lesson.Href,
+    Domain: domain,
+  }
+  article := templates.NewTechArticle(pg)
+  if len(lesson.Author) != 0 {
+    article.Author = &templates.Person{Type: "Person", Name: lesson.Author}
+  }
+  if !lesson.Updated.IsZero() {
+    article.DateModified = &lesson.Updated
+  }
+  article.TimeRequired = templates.ISODuration(lesson.Duration)
+  pg.StructuredData = []templates.StructuredData{
+    article,
+    templates.LessonBreadcrumbs(domain, lesson),
+  }
+  for _, data := range pg.StructuredData {
+    if err := data.Validate(); err != nil {
+      return err
+    }
+  }
+
+  page := &claatPage{PageMetadata: pg, Lesson: lesson}
+  head := new(bytes.Buffer)
+  if err := templates.Templates().ExecuteTemplate(head, "claat-head", page); err != nil {
+    return err
+  }
+  bar := new(bytes.Buffer)
+  if err := templates.Templates().ExecuteTemplate(bar, "claat-bar", page); err != nil {
+    return err
+  }
+
+  doc.Find(`link[rel="canonical"]`).Remove()
+  doc.Find("head").AppendHtml(head.String())
+  doc.Find("body").PrependHtml(bar.String())
+
+  html, err := doc.Html()
+  if err != nil {
+    return err
+  }
+  return ioutil.WriteFile(pagePath, []byte(html), 0644)
 }
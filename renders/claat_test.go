@@ -0,0 +1,241 @@
+package renders
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cobookman/gcp-quickstart/layout"
+)
+
+func TestReadCodelab(t *testing.T) {
+	dir, err := ioutil.TempDir("", "claat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "codelab.json")
+	if err := ioutil.WriteFile(path, []byte(`{"id": "gce-intro", "title": "Intro to GCE", "summary": "Start a VM",
+		"author": "Ada", "category": ["compute"], "duration": 45, "updated": "2017-03-02T10:00:00Z"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	codelab, err := readCodelab(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if codelab.Title != "Intro to GCE" || codelab.Duration != 45 || codelab.Categories[0] != "compute" ||
+		!codelab.Updated.Equal(time.Date(2017, 3, 2, 10, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected codelab %+v", codelab)
+	}
+}
+
+func TestClaatRenderApply(t *testing.T) {
+	updated := time.Date(2017, 3, 2, 0, 0, 0, 0, time.UTC)
+	cr := &ClaatRender{Codelab: &ClaatCodelab{
+		Title:    "Intro to GCE",
+		Summary:  "Start a VM",
+		Author:   "Ada",
+		Duration: 45,
+		Updated:  &updated,
+	}}
+
+	lesson := &layout.Lesson{Name: "intro"}
+	cr.apply(lesson)
+	if lesson.Summary != "Start a VM" || lesson.Author != "Ada" || lesson.Duration != 45*time.Minute ||
+		!lesson.Updated.Equal(updated) {
+		t.Fatalf("unexpected lesson %+v", lesson)
+	}
+	if len(cr.Warnings) != 0 {
+		t.Fatalf("unexpected warnings %v", cr.Warnings)
+	}
+
+	cr.apply(&layout.Lesson{Name: "Deploying App Engine"})
+	if len(cr.Warnings) != 1 {
+		t.Fatalf("expected a warning for the drifted title, got %v", cr.Warnings)
+	}
+}
+
+// Writes a shell script standing in for claat, giving its path
+func fakeClaat(t *testing.T, dir string, script string) string {
+	path := filepath.Join(dir, "claat")
+	if err := ioutil.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCheckClaat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "claat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	version, err := CheckClaat(fakeClaat(t, dir, `echo "claat version v2.1.0"`))
+	if err != nil || version != "2.1.0" {
+		t.Fatalf("expected version 2.1.0, got %s %v", version, err)
+	}
+	for _, out := range []string{"dev", "claat version dev"} {
+		if version, err := CheckClaat(fakeClaat(t, dir, `echo "`+out+`"`)); err != nil || version != DevVersion {
+			t.Fatalf("expected %q to be accepted as a dev build, got %s %v", out, version, err)
+		}
+	}
+	if _, err := CheckClaat(fakeClaat(t, dir, `echo "0.9"`)); err == nil {
+		t.Fatal("expected claat 0.9 to be too old")
+	}
+	if _, err := CheckClaat(filepath.Join(dir, "missing")); err == nil {
+		t.Fatal("expected a missing claat to fail")
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	for _, c := range []struct {
+		a, b string
+		want int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"1.2", "1.2.0", 0},
+		{"1.10.0", "1.9.3", 1},
+		{"0.9.9", "1.0.0", -1},
+	} {
+		if got := compareVersions(c.a, c.b); got != c.want {
+			t.Fatalf("compareVersions(%s, %s) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestRenderClaatFailures(t *testing.T) {
+	dir, err := ioutil.TempDir("", "claat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	lesson := &layout.Lesson{SourceClaat: "doc-id", Href: "/compute/gce/intro/index.html"}
+
+	_, err = RenderClaat(lesson, fakeClaat(t, dir, `echo "bad source doc" >&2; exit 1`), "", dir, "https://example.com")
+	if err == nil || !strings.Contains(err.Error(), "bad source doc") {
+		t.Fatalf("expected claat's stderr in the error, got %v", err)
+	}
+
+	_, err = RenderClaat(lesson, fakeClaat(t, dir, "exit 0"), "", dir, "https://example.com")
+	if err == nil || !strings.Contains(err.Error(), "exported 0 codelabs") {
+		t.Fatalf("expected an empty export to fail, got %v", err)
+	}
+}
+
+// A claat which exports a codelab the way claat does, into a folder named
+// after the codelab's id within the -o folder
+const exportingClaat = `while [ $# -gt 0 ]; do
+  if [ "$1" = "-o" ]; then out="$2"; fi
+  shift
+done
+mkdir -p "$out/gce-intro/img"
+echo "png" > "$out/gce-intro/img/step.png"
+cat > "$out/gce-intro/codelab.json" <<'JSON'
+{"id": "gce-intro", "title": "Intro to GCE", "summary": "Start a VM", "author": "Ada", "duration": 45}
+JSON
+cat > "$out/gce-intro/index.html" <<'HTML'
+<!doctype html>
+<html><head><title>Intro to GCE</title><link rel="canonical" href="https://codelabs.example.com/gce-intro"><style>body{}</style></head>
+<body><google-codelab title="Intro to GCE"><google-codelab-step label="Overview"><p>Hi</p></google-codelab-step></google-codelab></body></html>
+HTML`
+
+// Runs the test from the repo's root, where templates are parsed from
+func inRepoRoot(t *testing.T) func() {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(".."); err != nil {
+		t.Fatal(err)
+	}
+	return func() { os.Chdir(wd) }
+}
+
+func testClaatLesson() *layout.Lesson {
+	category := &layout.Category{ID: "compute", Name: "Compute"}
+	product := &layout.Product{Category: category, ID: "gce", Name: "Compute Engine"}
+	return &layout.Lesson{Product: product, Name: "intro", SourceClaat: "doc-id", Href: "/compute/gce/intro/index.html"}
+}
+
+func TestRenderClaat(t *testing.T) {
+	defer inRepoRoot(t)()
+	dir, err := ioutil.TempDir("", "claat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	buildFolder := filepath.Join(dir, "build")
+
+	lesson := testClaatLesson()
+	ctx := &BuildContext{ClaatPath: fakeClaat(t, dir, exportingClaat), BuildFolder: buildFolder, Domain: "https://example.com"}
+	out, err := claatRenderer{}.Render(lesson, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.AMP || len(out.Pages) != 1 {
+		t.Fatalf("expected one page which is not AMP, got %+v", out)
+	}
+
+	lessonFolder := filepath.Join(buildFolder, "compute", "gce", "intro")
+	for _, name := range []string{"index.html", "codelab.json", filepath.Join("img", "step.png")} {
+		if _, err := os.Stat(filepath.Join(lessonFolder, name)); err != nil {
+			t.Fatalf("expected %s in the lesson's folder: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(lessonFolder, "gce-intro")); err == nil {
+		t.Fatal("expected the export's contents to be copied, not its folder")
+	}
+	if lesson.Author != "Ada" || lesson.Summary != "Start a VM" || lesson.Duration != 45*time.Minute {
+		t.Fatalf("expected the lesson to be filled in from codelab.json, got %+v", lesson)
+	}
+}
+
+func TestWrapClaat(t *testing.T) {
+	defer inRepoRoot(t)()
+	dir, err := ioutil.TempDir("", "claat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the page the exporting claat writes
+	lesson := testClaatLesson()
+	lesson.Summary, lesson.Author = "Start a VM", "Ada"
+	pagePath := filepath.Join(dir, "compute", "gce", "intro", "index.html")
+	if err := os.MkdirAll(filepath.Dir(pagePath), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	page := exportingClaat[strings.Index(exportingClaat, "<!doctype"):strings.LastIndex(exportingClaat, "HTML")]
+	if err := ioutil.WriteFile(pagePath, []byte(page), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := wrapClaat(lesson, dir, "https://example.com"); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(pagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrapped := string(b)
+	for _, want := range []string{
+		`<link rel="canonical" href="https://example.com/compute/gce/intro/index.html"`,
+		`"@type":"TechArticle"`,
+		`<nav class="site-bar">`,
+		`<a href="/compute/gce/index.html">Compute Engine</a>`,
+		`<google-codelab title="Intro to GCE">`,
+	} {
+		if !strings.Contains(wrapped, want) {
+			t.Fatalf("missing %s in %s", want, wrapped)
+		}
+	}
+	if strings.Contains(wrapped, "codelabs.example.com") {
+		t.Fatal("expected claat's own canonical url to be replaced")
+	}
+}
@@ -0,0 +1,301 @@
+package renders
+
+import (
+	"strings"
+
+	"github.com/alecthomas/chroma"
+	"github.com/alecthomas/chroma/lexers"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	codeFence = "```"
+)
+
+// fonts which mark a gdoc paragraph as a line of code
+var monospaceFonts = []string{
+	"courier", "consolas", "inconsolata", "menlo", "monaco", "mono", "source code",
+}
+
+// Replaces runs of code in the gdoc with a single highlighted
+// <pre><code class="language-x"> block. A run of code is either consecutive
+// monospace paragraphs, a single cell table containing only monospace text,
+// or any paragraphs fenced by "```lang" and "```" paragraphs.
+func (gr *GdocRender) renderCodeBlocks(body *html.Node) {
+	var run []*html.Node
+	var lines []string
+	lang := ""
+	fenced := false
+
+	// replaces the nodes of the current run with a code block
+	flush := func() {
+		if len(run) == 0 {
+			return
+		}
+		if code := strings.Trim(strings.Join(lines, "\n"), "\n"); len(code) != 0 {
+			pre := highlightCode(code, lang)
+			body.InsertBefore(pre, run[0])
+			gr.markVerbatim(pre)
+		}
+		for _, n := range run {
+			body.RemoveChild(n)
+		}
+		run, lines, lang, fenced = nil, nil, "", false
+	}
+
+	// the "```lang" paragraph opening the current fence, and one found never
+	// to be closed, which is then left as text
+	var opening, unclosed *html.Node
+	var next *html.Node
+	for n := body.FirstChild; ; n = next {
+		if n == nil {
+			if !fenced {
+				break
+			}
+			// go back over what followed the fence as if it were not there
+			gr.warn("code fence \"" + strings.TrimSpace(codeText(opening)) + "\" is never closed, so is left as text")
+			unclosed = opening
+			run, lines, lang, fenced = nil, nil, "", false
+			if n = opening.NextSibling; n == nil {
+				break
+			}
+		}
+		next = n.NextSibling
+		if n.Type != html.ElementNode {
+			continue
+		}
+
+		if hint, ok := codeFenceHint(n); ok && n != unclosed {
+			if fenced {
+				// closing fence
+				run = append(run, n)
+				flush()
+				continue
+			}
+			flush()
+			run = append(run, n)
+			lang, fenced, opening = hint, true, n
+			continue
+		}
+
+		switch {
+		case fenced:
+			// everything up to the closing fence is code, keeping the text of
+			// elements other than paragraphs and code tables
+			run = append(run, n)
+			paragraphs := findAll(n, atom.P)
+			if n.DataAtom == atom.P || len(paragraphs) == 0 {
+				lines = append(lines, codeText(n))
+			} else {
+				for _, p := range paragraphs {
+					lines = append(lines, codeText(p))
+				}
+			}
+			if n.DataAtom != atom.P && !(n.DataAtom == atom.Table && isSingleCellTable(n)) {
+				gr.warn("<" + n.Data + "> inside the code fence \"" + strings.TrimSpace(codeText(opening)) + "\" is kept as code text")
+			}
+
+		case n.DataAtom == atom.P && gr.isMonospace(n):
+			run = append(run, n)
+			lines = append(lines, codeText(n))
+
+		case n.DataAtom == atom.Table && gr.isCodeTable(n) && isSingleCellTable(n):
+			flush()
+			run = append(run, n)
+			for _, p := range findAll(n, atom.P) {
+				lines = append(lines, codeText(p))
+			}
+			flush()
+
+		default:
+			flush()
+		}
+	}
+	flush()
+}
+
+// Marks a node built by the renderer so that cleanNode leaves it as is.
+func (gr *GdocRender) markVerbatim(n *html.Node) {
+	if gr.verbatim == nil {
+		gr.verbatim = make(map[*html.Node]bool)
+	}
+	gr.verbatim[n] = true
+}
+
+// If the node is a "```lang" or "```" paragraph, gives the language hint.
+func codeFenceHint(n *html.Node) (string, bool) {
+	if n.DataAtom != atom.P {
+		return "", false
+	}
+	text := strings.TrimSpace(codeText(n))
+	if !strings.HasPrefix(text, codeFence) {
+		return "", false
+	}
+	hint := strings.TrimSpace(strings.TrimPrefix(text, codeFence))
+	if strings.ContainsAny(hint, " `") {
+		return "", false
+	}
+	return strings.ToLower(hint), true
+}
+
+// True if every character of text in the node is in a monospace font. Blank
+// paragraphs count as code when they are themselves set in a monospace font.
+func (gr GdocRender) isMonospace(n *html.Node) bool {
+	hasText := false
+	isMonospace := true
+	walkText(n, func(t *html.Node) {
+		if len(strings.TrimSpace(strings.Replace(t.Data, "\u00a0", " ", -1))) == 0 {
+			return
+		}
+		hasText = true
+		if !isMonospaceFont(gr.inheritedStyle(t.Parent, "font-family")) {
+			isMonospace = false
+		}
+	})
+	if hasText {
+		return isMonospace
+	}
+
+	if isMonospaceFont(gr.nodeStyle(n, "font-family")) {
+		return true
+	}
+	for _, span := range findAll(n, atom.Span) {
+		if isMonospaceFont(gr.nodeStyle(span, "font-family")) {
+			return true
+		}
+	}
+	return false
+}
+
+// True if the table's single cell only contains monospace paragraphs.
+func (gr GdocRender) isCodeTable(n *html.Node) bool {
+	hasText := false
+	for _, p := range findAll(n, atom.P) {
+		if !gr.isMonospace(p) {
+			return false
+		}
+		if len(strings.TrimSpace(codeText(p))) != 0 {
+			hasText = true
+		}
+	}
+	return hasText
+}
+
+func isSingleCellTable(n *html.Node) bool {
+	return len(findAll(n, atom.Td)) == 1
+}
+
+func isMonospaceFont(fontFamily string) bool {
+	fontFamily = strings.ToLower(fontFamily)
+	for _, font := range monospaceFonts {
+		if strings.Contains(fontFamily, font) {
+			return true
+		}
+	}
+	return false
+}
+
+// Gives the text of a node as written in the gdoc, keeping line breaks and
+// turning non-breaking spaces, which gdocs use for indentation, into spaces.
+func codeText(n *html.Node) string {
+	text := ""
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			text += strings.Replace(n.Data, "\u00a0", " ", -1)
+		case n.DataAtom == atom.Br:
+			text += "\n"
+		case n.DataAtom == atom.A && strings.HasPrefix(nodeAttr(n, "href"), commentPrefix):
+			// comment anchors are not part of the code
+		default:
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				walk(c)
+			}
+		}
+	}
+	walk(n)
+	return text
+}
+
+// Builds a <pre><code> block of the code highlighted at build time, as AMP
+// pages cannot run a highlighting script. Unknown languages are left plain.
+func highlightCode(code string, lang string) *html.Node {
+	var lexer chroma.Lexer
+	if len(lang) != 0 {
+		lexer = lexers.Get(lang)
+	} else if lexer = lexers.Analyse(code); lexer != nil {
+		lang = strings.ToLower(lexer.Config().Name)
+		if aliases := lexer.Config().Aliases; len(aliases) != 0 {
+			lang = aliases[0]
+		}
+	}
+	if len(lang) == 0 {
+		lang = "text"
+	}
+
+	pre := &html.Node{Type: html.ElementNode, DataAtom: atom.Pre, Data: "pre"}
+	codeNode := &html.Node{
+		Type:     html.ElementNode,
+		DataAtom: atom.Code,
+		Data:     "code",
+		Attr:     []html.Attribute{{Key: "class", Val: "language-" + lang}},
+	}
+	pre.AppendChild(codeNode)
+
+	tokens := []chroma.Token{{Type: chroma.Text, Value: code}}
+	if lexer != nil {
+		if it, err := chroma.Coalesce(lexer).Tokenise(nil, code); err == nil {
+			tokens = it.Tokens()
+		}
+	}
+
+	for _, token := range tokens {
+		text := &html.Node{Type: html.TextNode, Data: token.Value}
+		class := tokenClass(token.Type)
+		if len(class) == 0 {
+			codeNode.AppendChild(text)
+			continue
+		}
+		span := &html.Node{
+			Type:     html.ElementNode,
+			DataAtom: atom.Span,
+			Data:     "span",
+			Attr:     []html.Attribute{{Key: "class", Val: class}},
+		}
+		span.AppendChild(text)
+		codeNode.AppendChild(span)
+	}
+	return pre
+}
+
+// Gives the css class of a highlighted token. Classes follow the pygments
+// short names, see the "styles" template.
+func tokenClass(tt chroma.TokenType) string {
+	switch {
+	case tt == chroma.NameBuiltin || tt == chroma.NameBuiltinPseudo:
+		return "nb"
+	case tt == chroma.NameFunction || tt == chroma.NameFunctionMagic:
+		return "nf"
+	case tt >= chroma.NameVariable && tt <= chroma.NameVariableMagic:
+		return "nv"
+	case tt == chroma.NameTag:
+		return "nt"
+	case tt == chroma.NameAttribute:
+		return "na"
+	case tt == chroma.GenericPrompt:
+		return "gp"
+	case tt.InCategory(chroma.Comment):
+		return "c"
+	case tt.InCategory(chroma.Keyword):
+		return "k"
+	case tt.InSubCategory(chroma.LiteralString):
+		return "s"
+	case tt.InSubCategory(chroma.LiteralNumber):
+		return "m"
+	case tt.InCategory(chroma.Operator):
+		return "o"
+	}
+	return ""
+}
@@ -0,0 +1,115 @@
+package renders
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const metadataTable = `<table><tr><td>Title</td><td>Test</td></tr></table>`
+
+// Renders the article body of a gdoc export made of the given css and body,
+// stripping the metadata table as rendering a gdoc does
+func renderFixture(t *testing.T, css string, body string) *GdocRender {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(
+		"<html><head><style>" + css + "</style></head><body>" + metadataTable + body + "</body></html>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gr := &GdocRender{styles: parseStyles(doc.Find("style").Text())}
+	removeMetadataTable(doc.Find("body"))
+	if err := gr.renderArticleBody(doc.Find("body")); err != nil {
+		t.Fatal(err)
+	}
+	return gr
+}
+
+func TestRenderCodeBlocksMonospaceRun(t *testing.T) {
+	gr := renderFixture(t, `.c1{font-family:"Courier New"}.c2{font-family:"Arial"}`,
+		`<p class="c2"><span>Run:</span></p>`+
+			`<p><span class="c1">gcloud&nbsp;&nbsp;init</span></p>`+
+			`<p><span class="c1"></span></p>`+
+			`<p><span class="c1">gcloud app deploy</span></p>`+
+			`<p class="c2"><span>Done</span></p>`)
+
+	if strings.Count(gr.ArticleHTML, "<pre>") != 1 {
+		t.Fatal("expected a single code block: " + gr.ArticleHTML)
+	}
+	if !strings.Contains(gr.ArticleHTML, "gcloud  init\n\ngcloud app deploy") {
+		t.Fatal("code whitespace not preserved: " + gr.ArticleHTML)
+	}
+	if !strings.Contains(gr.ArticleHTML, "<p>Run:</p>") || !strings.Contains(gr.ArticleHTML, "<p>Done</p>") {
+		t.Fatal("text around the code block was lost: " + gr.ArticleHTML)
+	}
+}
+
+func TestRenderCodeBlocksFenced(t *testing.T) {
+	gr := renderFixture(t, "",
+		"<p><span>```go</span></p>"+
+			"<p><span>func main() {}</span></p>"+
+			"<p><span>```</span></p>")
+
+	if !strings.Contains(gr.ArticleHTML, `<code class="language-go">`) {
+		t.Fatal("language hint not applied: " + gr.ArticleHTML)
+	}
+	if !strings.Contains(gr.ArticleHTML, `<span class="k">func</span>`) {
+		t.Fatal("code not highlighted: " + gr.ArticleHTML)
+	}
+	if strings.Contains(gr.ArticleHTML, "```") {
+		t.Fatal("code fences were rendered: " + gr.ArticleHTML)
+	}
+}
+
+func TestRenderCodeBlocksTable(t *testing.T) {
+	gr := renderFixture(t, `.c1{font-family:"Consolas"}`,
+		`<table><tr><td><p><span class="c1">ls -la</span></p><p><span class="c1">pwd</span></p></td></tr></table>`+
+			`<table><tr><td><p><span>Not code</span></p></td></tr></table>`)
+
+	if !strings.Contains(gr.ArticleHTML, "<pre><code") || !strings.Contains(gr.ArticleHTML, "ls -la\npwd") {
+		t.Fatal("code table not rendered as a code block: " + gr.ArticleHTML)
+	}
+	if !strings.Contains(gr.ArticleHTML, "<table><tbody><tr><td>Not code</td></tr></tbody></table>") {
+		t.Fatal("regular table rendered as code: " + gr.ArticleHTML)
+	}
+}
+
+func TestRenderCodeBlocksUnclosedFence(t *testing.T) {
+	gr := renderFixture(t, `.c1{font-family:"Courier New"}`,
+		"<p><span>```go</span></p>"+
+			"<p><span>Not code</span></p>"+
+			`<p><span class="c1">ls</span></p>`+
+			"<p><span>Still not code</span></p>")
+
+	if !strings.Contains(gr.ArticleHTML, "<p>```go</p><p>Not code</p>") ||
+		!strings.Contains(gr.ArticleHTML, "<p>Still not code</p>") {
+		t.Fatal("text after an unclosed fence was rendered as code: " + gr.ArticleHTML)
+	}
+	if strings.Count(gr.ArticleHTML, "<pre>") != 1 || !strings.Contains(gr.ArticleHTML, ">ls</") {
+		t.Fatal("monospace runs after an unclosed fence were not rendered as code: " + gr.ArticleHTML)
+	}
+	if len(gr.Warnings) != 1 || !strings.Contains(gr.Warnings[0], "never closed") {
+		t.Fatalf("expected a warning for the unclosed fence, got %v", gr.Warnings)
+	}
+}
+
+func TestRenderCodeBlocksTableInFence(t *testing.T) {
+	gr := renderFixture(t, "",
+		"<p><span>```</span></p>"+
+			"<p><span>before</span></p>"+
+			`<table><tr><td><p><span>a</span></p></td><td><p><span>b</span></p></td></tr></table>`+
+			"<p><span>after</span></p>"+
+			"<p><span>```</span></p>"+
+			"<p><span>Not code</span></p>")
+
+	if strings.Count(gr.ArticleHTML, "<pre>") != 1 || !strings.Contains(gr.ArticleHTML, "before\na\nb\nafter") {
+		t.Fatal("expected the table kept as text of a single code block: " + gr.ArticleHTML)
+	}
+	if !strings.HasSuffix(gr.ArticleHTML, "<p>Not code</p>") || strings.Contains(gr.ArticleHTML, "```") {
+		t.Fatal("expected the fence closed after the table: " + gr.ArticleHTML)
+	}
+	if len(gr.Warnings) != 1 || !strings.Contains(gr.Warnings[0], "<table>") {
+		t.Fatalf("expected a warning for the table, got %v", gr.Warnings)
+	}
+}
@@ -0,0 +1,95 @@
+package renders
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	commentRefPrefix = "#cmnt_ref"
+	// characters of the commented text kept as the comment's context
+	commentContextLength = 80
+)
+
+// An open comment thread left on the gdoc
+type GdocComment struct {
+	ID      string
+	Text    string
+	Context string
+}
+
+// Collects the gdoc's open comments and removes the comment threads gdoc
+// exports at the end of the document. A comment's context is the text just
+// before its anchor, which gdoc places at the end of the commented text.
+func (gr *GdocRender) renderComments(body *html.Node) {
+	gr.Comments = nil
+
+	// the text leading up to every comment anchor, keyed by comment id
+	contexts := make(map[string]string)
+	for _, a := range findAll(body, atom.A) {
+		href := nodeAttr(a, "href")
+		if !strings.HasPrefix(href, commentPrefix) || strings.HasPrefix(href, commentRefPrefix) {
+			continue
+		}
+		block := a
+		for block.Parent != nil && block.Parent != body {
+			block = block.Parent
+		}
+		context := ""
+		for n := block.FirstChild; n != nil; n = nextNode(n, block) {
+			if n == a {
+				break
+			}
+			if n.Type == html.TextNode {
+				context += n.Data
+			}
+		}
+		contexts[strings.TrimPrefix(href, "#")] = lastChars(strings.Join(strings.Fields(context), " "), commentContextLength)
+	}
+
+	var next *html.Node
+	for n := body.FirstChild; n != nil; n = next {
+		next = n.NextSibling
+		if n.DataAtom != atom.Div {
+			continue
+		}
+		for _, a := range findAll(n, atom.A) {
+			if !strings.HasPrefix(nodeAttr(a, "href"), commentRefPrefix) {
+				continue
+			}
+			id := nodeAttr(a, "id")
+			a.Parent.RemoveChild(a)
+			gr.Comments = append(gr.Comments, &GdocComment{
+				ID:      id,
+				Text:    strings.Join(strings.Fields(codeText(n)), " "),
+				Context: contexts[id],
+			})
+			body.RemoveChild(n)
+			break
+		}
+	}
+}
+
+// Gives the node after n in document order, staying within root
+func nextNode(n *html.Node, root *html.Node) *html.Node {
+	if n.FirstChild != nil {
+		return n.FirstChild
+	}
+	for ; n != nil && n != root; n = n.Parent {
+		if n.NextSibling != nil {
+			return n.NextSibling
+		}
+	}
+	return nil
+}
+
+// Gives at most the last n characters of the text
+func lastChars(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	return "…" + string(runes[len(runes)-n:])
+}
@@ -0,0 +1,46 @@
+package renders
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderComments(t *testing.T) {
+	body := parseBody(t,
+		`<p><span>Run gcloud init first</span><sup><a href="#cmnt1" id="cmnt_ref1">[a]</a></sup><span>.</span></p>`+
+			`<div style="border:1px solid black;margin:5px"><p><a href="#cmnt_ref1" id="cmnt1">[a]</a><span>TODO verify this command</span></p></div>`+
+			`<div class="c9"><p><a href="#cmnt_ref2" id="cmnt2">[b]</a><span>Orphaned</span></p></div>`)
+	gr := new(GdocRender)
+	gr.renderComments(body)
+
+	// the anchors are removed when links are cleaned
+	want := `<p><span>Run gcloud init first</span><sup><a href="#cmnt1" id="cmnt_ref1">[a]</a></sup><span>.</span></p>`
+	if got := renderChildren(t, body); got != want {
+		t.Fatalf("expected the comment threads removed, got %s", got)
+	}
+	if len(gr.Comments) != 2 {
+		t.Fatalf("expected 2 comments, got %d", len(gr.Comments))
+	}
+	comment := gr.Comments[0]
+	if comment.ID != "cmnt1" || comment.Text != "TODO verify this command" || comment.Context != "Run gcloud init first" {
+		t.Fatalf("unexpected comment %+v", comment)
+	}
+	if gr.Comments[1].Text != "Orphaned" || len(gr.Comments[1].Context) != 0 {
+		t.Fatalf("unexpected comment %+v", gr.Comments[1])
+	}
+}
+
+func TestCommentContextIsCut(t *testing.T) {
+	long := strings.Repeat("word ", 30)
+	body := parseBody(t,
+		`<h2>Heading</h2><p>`+long+`<b>end</b><a href="#cmnt1">[a]</a></p>`+
+			`<div><p><a href="#cmnt_ref1" id="cmnt1">[a]</a>Shorten this</p></div>`)
+	gr := new(GdocRender)
+	gr.renderComments(body)
+
+	context := gr.Comments[0].Context
+	if !strings.HasPrefix(context, "…") || !strings.HasSuffix(context, "word end") ||
+		len([]rune(context)) != commentContextLength+1 {
+		t.Fatalf("expected the last %d characters of the paragraph, got %q", commentContextLength, context)
+	}
+}
@@ -0,0 +1,94 @@
+package renders
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	htmlExt = ".html"
+	zipExt  = ".zip"
+)
+
+// Whether a gdoc source is a file downloaded from google docs as a web page,
+// either the .html alone or the .zip of the html along with its images
+func isLocalExport(source string) bool {
+	ext := strings.ToLower(filepath.Ext(source))
+	return ext == htmlExt || ext == ".htm" || ext == zipExt
+}
+
+// Opens a gdoc's html export, giving the document and when it was last
+// modified. The export's images are found relative to it.
+func (gr *GdocRender) openExport(exportPath string) (*goquery.Document, time.Time, error) {
+	f, err := os.Open(exportPath)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(f)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	gr.sourceDir = filepath.Dir(exportPath)
+	return doc, info.ModTime(), nil
+}
+
+// Extracts a zipped gdoc export into folder, giving the path of its html
+func unzipExport(zipPath string, folder string) (string, error) {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	var htmlPaths []string
+	for _, f := range r.File {
+		name := path.Clean("/" + f.Name)[1:]
+		if f.FileInfo().IsDir() || len(name) == 0 {
+			continue
+		}
+		filePath := filepath.Join(folder, filepath.FromSlash(name))
+		if err := unzipFile(f, filePath); err != nil {
+			return "", err
+		}
+		if strings.ToLower(path.Ext(name)) == htmlExt && !strings.Contains(name, "/") {
+			htmlPaths = append(htmlPaths, filePath)
+		}
+	}
+	if len(htmlPaths) != 1 {
+		return "", fmt.Errorf("%s has %d html files, expected 1", zipPath, len(htmlPaths))
+	}
+	return htmlPaths[0], nil
+}
+
+func unzipFile(f *zip.File, filePath string) error {
+	if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+		return err
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	out, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, rc)
+	return err
+}
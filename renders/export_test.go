@@ -0,0 +1,115 @@
+package renders
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Zips files, keyed by their name in the zip, into dir
+func writeZip(t *testing.T, dir string, files map[string]string) string {
+	zipPath := filepath.Join(dir, "export.zip")
+	f, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	for name, contents := range files {
+		fw, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write([]byte(contents))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return zipPath
+}
+
+func TestIsLocalExport(t *testing.T) {
+	for source, want := range map[string]bool{
+		"exports/Doc.html": true,
+		"exports/Doc.ZIP":  true,
+		"https://docs.google.com/document/d/1AbC-d_E/edit": false,
+		"1AbC-d_E": false,
+	} {
+		if isLocalExport(source) != want {
+			t.Fatalf("isLocalExport(%s) = %v, want %v", source, !want, want)
+		}
+	}
+}
+
+func TestUnzipExport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "export-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	zipPath := writeZip(t, dir, map[string]string{
+		"Doc.html":          `<html><body><p>hi <img src="images/image1.png"></p></body></html>`,
+		"images/image1.png": "png",
+		"../escaped.txt":    "x",
+	})
+	folder := filepath.Join(dir, "out")
+	htmlPath, err := unzipExport(zipPath, folder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if htmlPath != filepath.Join(folder, "Doc.html") {
+		t.Fatalf("unexpected html path %s", htmlPath)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escaped.txt")); err == nil {
+		t.Fatal("expected files to be kept inside the folder")
+	}
+
+	gr := new(GdocRender)
+	doc, _, err := gr.openExport(htmlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, _ := doc.Find("img").Attr("src")
+	b, _, err := gr.fetchImage(src)
+	if err != nil || string(b) != "png" {
+		t.Fatalf("expected the image next to the export, got %q %v", b, err)
+	}
+
+	if _, err := unzipExport(writeZip(t, dir, map[string]string{"a.txt": "a"}), folder); err == nil {
+		t.Fatal("expected a zip without html to fail")
+	}
+}
+
+func TestFetchImageOutsideSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "export-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sourceDir := filepath.Join(dir, "doc")
+	if err := os.MkdirAll(filepath.Join(sourceDir, "images"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	for path, content := range map[string]string{
+		filepath.Join(sourceDir, "images", "image1.png"): "png",
+		filepath.Join(dir, "secret.png"):                 "secret",
+	} {
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gr := &GdocRender{sourceDir: sourceDir}
+	if b, _, err := gr.fetchImage("images/../images/image1.png"); err != nil || string(b) != "png" {
+		t.Fatalf("expected the image inside the source folder, got %q %v", b, err)
+	}
+	for _, src := range []string{"../secret.png", "images/../../secret.png", ".."} {
+		if b, _, err := gr.fetchImage(src); err == nil {
+			t.Fatalf("expected %s to be rejected, got %q", src, b)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package renders
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	footnotePrefix    = "#ftnt"
+	footnoteRefPrefix = "#ftnt_ref"
+)
+
+// Gdoc exports footnotes as <sup><a href="#ftnt1"> references, and a list of
+// footnotes at the end of the document linking back with "#ftnt_ref1".
+// Renumbers the footnotes in the order they are referenced and rebuilds them
+// as a <section class="footnotes"> with working links in both directions.
+func (gr *GdocRender) renderFootnotes(body *html.Node) {
+	// footnote contents keyed by the gdoc's footnote id, such as ftnt1
+	definitions := make(map[string]*html.Node)
+	var ids []string
+	var first *html.Node
+	for n := body.FirstChild; n != nil; n = n.NextSibling {
+		if n.DataAtom != atom.Div {
+			continue
+		}
+		for _, a := range findAll(n, atom.A) {
+			if strings.HasPrefix(nodeAttr(a, "href"), footnoteRefPrefix) {
+				removeFootnoteAnchor(a)
+				definitions[nodeAttr(a, "id")] = n
+				ids = append(ids, nodeAttr(a, "id"))
+				if first == nil {
+					first = n
+				}
+				break
+			}
+		}
+	}
+	if len(definitions) == 0 {
+		return
+	}
+
+	// the rule gdoc draws above its footnotes
+	prev := first.PrevSibling
+	for prev != nil && prev.Type == html.TextNode && len(strings.TrimSpace(prev.Data)) == 0 {
+		prev = prev.PrevSibling
+	}
+	if prev != nil && prev.DataAtom == atom.Hr {
+		body.RemoveChild(prev)
+	}
+	for _, definition := range definitions {
+		body.RemoveChild(definition)
+	}
+
+	// footnote numbers keyed by gdoc id, and the ids of each footnote's
+	// references in the order they are made
+	numbers := make(map[string]string)
+	var order []string
+	refIDs := make(map[string][]string)
+	for _, ref := range findAll(body, atom.A) {
+		href := nodeAttr(ref, "href")
+		if !strings.HasPrefix(href, footnotePrefix) || strings.HasPrefix(href, footnoteRefPrefix) {
+			continue
+		}
+		id := strings.TrimPrefix(href, "#")
+		if _, ok := definitions[id]; !ok {
+			gr.warn("footnote reference " + href + " has no footnote")
+			continue
+		}
+
+		number, ok := numbers[id]
+		if !ok {
+			number = strconv.Itoa(len(numbers) + 1)
+			numbers[id] = number
+			order = append(order, id)
+		}
+		// a footnote cited again gets a reference id of its own, such as fnref-1-2
+		refID := "fnref-" + number
+		if n := len(refIDs[id]); n != 0 {
+			refID += "-" + strconv.Itoa(n+1)
+		}
+		refIDs[id] = append(refIDs[id], refID)
+
+		for c := ref.FirstChild; c != nil; c = ref.FirstChild {
+			ref.RemoveChild(c)
+		}
+		ref.AppendChild(&html.Node{Type: html.TextNode, Data: number})
+		setNodeAttr(ref, "href", "#fn-"+number)
+		gr.keepAttr(ref, "id", refID)
+	}
+
+	for _, id := range ids {
+		if _, ok := numbers[id]; !ok {
+			gr.warn("footnote " + id + " is never referenced")
+		}
+	}
+	if len(order) == 0 {
+		return
+	}
+
+	list := &html.Node{Type: html.ElementNode, DataAtom: atom.Ol, Data: "ol"}
+	for _, id := range order {
+		list.AppendChild(gr.footnote(numbers[id], definitions[id], refIDs[id]))
+	}
+	section := &html.Node{Type: html.ElementNode, DataAtom: atom.Section, Data: "section"}
+	gr.keepAttr(section, "class", "footnotes")
+	section.AppendChild(list)
+	body.AppendChild(section)
+}
+
+// Builds the list item of a footnote, with a link back to each of its
+// references
+func (gr *GdocRender) footnote(number string, definition *html.Node, refIDs []string) *html.Node {
+	li := &html.Node{Type: html.ElementNode, DataAtom: atom.Li, Data: "li"}
+	gr.keepAttr(li, "id", "fn-"+number)
+	for c := definition.FirstChild; c != nil; c = definition.FirstChild {
+		definition.RemoveChild(c)
+		li.AppendChild(c)
+	}
+
+	// keep the back links on the footnote's last line
+	last := li
+	if li.LastChild != nil && li.LastChild.DataAtom == atom.P {
+		last = li.LastChild
+	}
+	for _, refID := range refIDs {
+		backref := &html.Node{
+			Type:     html.ElementNode,
+			DataAtom: atom.A,
+			Data:     "a",
+			Attr:     []html.Attribute{{Key: "href", Val: "#" + refID}},
+		}
+		gr.keepAttr(backref, "class", "footnote-backref")
+		backref.AppendChild(&html.Node{Type: html.TextNode, Data: "↩"})
+		last.AppendChild(&html.Node{Type: html.TextNode, Data: " "})
+		last.AppendChild(backref)
+	}
+	return li
+}
+
+// Removes the "[1]" anchor gdoc starts a footnote with, and the space after it
+func removeFootnoteAnchor(a *html.Node) {
+	if next := a.NextSibling; next != nil {
+		trimmed := false
+		walkText(next, func(t *html.Node) {
+			if !trimmed {
+				t.Data = strings.TrimLeftFunc(t.Data, unicode.IsSpace)
+				trimmed = len(t.Data) != 0
+			}
+		})
+	}
+	a.Parent.RemoveChild(a)
+}
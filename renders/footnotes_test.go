@@ -0,0 +1,82 @@
+package renders
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Gives the attributes the renderer keeps on nodes of the atom once they are
+// cleaned, in document order
+func keptAttrs(gr *GdocRender, body *html.Node, a atom.Atom) [][]html.Attribute {
+	var attrs [][]html.Attribute
+	for _, n := range findAll(body, a) {
+		attrs = append(attrs, gr.attrs[n])
+	}
+	return attrs
+}
+
+func TestRenderFootnotes(t *testing.T) {
+	body := parseBody(t,
+		`<p><span>Preemptible VMs</span><sup><a href="#ftnt2" id="ftnt_ref2">[2]</a></sup>`+
+			`<span> and quotas</span><sup><a href="#ftnt1" id="ftnt_ref1">[1]</a></sup></p>`+
+			`<hr class="c3">`+
+			`<div><p><a href="#ftnt_ref1" id="ftnt1">[1]</a><span>&nbsp;Quotas are per project.</span></p></div>`+
+			`<div><p><a href="#ftnt_ref2" id="ftnt2">[2]</a><span>&nbsp;Up to 24 hours.</span></p></div>`)
+	gr := new(GdocRender)
+	gr.renderFootnotes(body)
+
+	want := `<p><span>Preemptible VMs</span><sup><a href="#fn-1" id="ftnt_ref2">1</a></sup>` +
+		`<span> and quotas</span><sup><a href="#fn-2" id="ftnt_ref1">2</a></sup></p>` +
+		`<section><ol>` +
+		`<li><p><span>Up to 24 hours.</span> <a href="#fnref-1">↩</a></p></li>` +
+		`<li><p><span>Quotas are per project.</span> <a href="#fnref-2">↩</a></p></li>` +
+		`</ol></section>`
+	if got := renderChildren(t, body); got != want {
+		t.Fatalf("got %s\nwant %s", got, want)
+	}
+	if len(gr.Warnings) != 0 {
+		t.Fatalf("unexpected warnings %v", gr.Warnings)
+	}
+
+	// gdoc's ids are cleaned from the references, leaving the ones kept here
+	ids := keptAttrs(gr, body, atom.Li)
+	refs := keptAttrs(gr, findAll(body, atom.P)[0], atom.A)
+	if ids[0][0].Val != "fn-1" || ids[1][0].Val != "fn-2" || refs[0][0].Val != "fnref-1" || refs[1][0].Val != "fnref-2" {
+		t.Fatalf("unexpected ids %v %v", ids, refs)
+	}
+}
+
+func TestRenderFootnotesWarnings(t *testing.T) {
+	body := parseBody(t,
+		`<p>a<sup><a href="#ftnt3" id="ftnt_ref3">[3]</a></sup></p>`+
+			`<div><p><a href="#ftnt_ref1" id="ftnt1">[1]</a> Never cited</p></div>`)
+	gr := new(GdocRender)
+	gr.renderFootnotes(body)
+
+	if len(gr.Warnings) != 2 {
+		t.Fatalf("expected warnings for the missing and unreferenced footnotes, got %v", gr.Warnings)
+	}
+	if len(findAll(body, atom.Section)) != 0 {
+		t.Fatalf("expected no footnotes section, got %s", renderChildren(t, body))
+	}
+}
+
+func TestRenderFootnoteCitedTwice(t *testing.T) {
+	body := parseBody(t,
+		`<p>Quotas<sup><a href="#ftnt1" id="ftnt_ref1">[1]</a></sup> apply per region<sup><a href="#ftnt1">[1]</a></sup></p>`+
+			`<div><p><a href="#ftnt_ref1" id="ftnt1">[1]</a> See the quotas page.</p></div>`)
+	gr := new(GdocRender)
+	gr.renderFootnotes(body)
+
+	refs := keptAttrs(gr, findAll(body, atom.P)[0], atom.A)
+	if len(refs) != 2 || refs[0][0].Val != "fnref-1" || refs[1][0].Val != "fnref-1-2" {
+		t.Fatalf("expected a unique id per reference, got %v", refs)
+	}
+	want := `<section><ol><li><p>See the quotas page. <a href="#fnref-1">↩</a> <a href="#fnref-1-2">↩</a></p></li></ol></section>`
+	if got := renderChildren(t, body); !strings.HasSuffix(got, want) {
+		t.Fatalf("expected a back link per reference, got %s", got)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"net/url"
 	"time"
 	"errors"
+	"fmt"
 	"github.com/PuerkitoBio/goquery"
 	"github.com/cobookman/gcp-quickstart/apiclients"
 	"github.com/cobookman/gcp-quickstart/layout"
@@ -22,7 +23,10 @@ import (
 	_ "image/gif"
 	_ "image/jpeg"
 	_ "image/png"
+	"mime"
 	"os"
+	"path"
+	"path/filepath"
 )
 
 const (
@@ -30,10 +34,34 @@ const (
 )
 
 type GdocMetadata struct {
-	Title   string
-	Summary string
-	Author  string
-	Image   string
+	Title       string
+	Summary     string
+	Author      string
+	Image       string
+	TOC         string
+	Tags        []string
+	Description string
+	Published   time.Time
+	Updated     time.Time
+	Canonical   string
+	Status      GdocStatus
+	Duration    time.Duration
+	Level       GdocLevel
+	Steps       GdocSteps
+}
+
+// Falls back to the gdoc's creation and modification times in drive for the
+// dates not given in the metadata table.
+func (m *GdocMetadata) useFileTimes(times *apiclients.FileTimes) {
+	if m.Published.IsZero() {
+		m.Published = times.Created
+	}
+	if m.Updated.IsZero() {
+		m.Updated = times.Modified
+	}
+	if m.Updated.Before(m.Published) {
+		m.Updated = m.Published
+	}
 }
 
 type GdocRender struct {
@@ -44,81 +72,315 @@ type GdocRender struct {
 	BuildFolder string
 	Domain 		 	string
 	Layout *layout.Layout
+	TOC         []*templates.TOCEntry
+	Warnings    []string
+	Comments    []*GdocComment
+	// AMP components used by the article, such as amp-youtube
+	AMPComponents []string
+	// Pages of the article, if its metadata splits it into steps
+	Steps []*GdocStep
+
+	// css rules from the gdoc's stylesheet, keyed by class name
+	styles gdocStyles
+	// nodes generated by the renderer which cleanNode must leave untouched
+	verbatim map[*html.Node]bool
+	// site hrefs of the gdocs published by the layout, keyed by gdoc id
+	docHrefs map[string]string
+	// links pointed at the pages of other docs, which are unlinked if those
+	// docs are found to be drafts once every doc is rendered
+	docLinks []*docLink
+	// the rendered article, kept until it is written
+	body *html.Node
+	// previews are not split into steps, so that the one page they write
+	// works wherever it is opened from
+	preview bool
+	// attributes to give nodes once their gdoc attributes are cleaned
+	attrs map[*html.Node][]html.Attribute
+	// generated heading ids keyed by the gdoc's heading ids
+	headingIDs map[string]string
+	// folder of local sources, which links to other markdown files are found in
+	contentDir string
+	// folder of a local source's own file, which its relative images are found in
+	sourceDir string
 }
 
 // Grabs the contents from a gdoc, downloads all images to the build folder.
-// fixes some html issues, and pases up any errors
+// fixes some html issues, and pases up any errors. The gdoc may also be given
+// as a local .html or .zip export, which needs no oauth. Its pages are left
+// to Write, so that every lesson is rendered, and its drafts known, before
+// any page is written.
 func RenderGdoc(layout *layout.Layout, clientSecretPath string, gdocURL string, buildFolder string, htmlPath string, domain string) (*GdocRender, error) {
+	return renderGdoc(layout, clientSecretPath, gdocURL, buildFolder, htmlPath, domain, false)
+}
+
+// Renders a local .html or .zip export of a gdoc to a single page for
+// previewing, writing it out even if the gdoc is a draft. Gdocs split into
+// steps are previewed whole, so that links between steps work without
+// serving the preview from the web root.
+func PreviewGdoc(layout *layout.Layout, exportPath string, buildFolder string, htmlPath string, domain string) (*GdocRender, error) {
+	if !isLocalExport(exportPath) {
+		return nil, errors.New(exportPath + " is not an .html or .zip export")
+	}
+	gr, err := renderGdoc(layout, "", exportPath, buildFolder, htmlPath, domain, true)
+	if err != nil {
+		return nil, err
+	}
+	return gr, gr.Write()
+}
+
+func renderGdoc(layout *layout.Layout, clientSecretPath string, gdocURL string, buildFolder string, htmlPath string, domain string, preview bool) (*GdocRender, error) {
 	gr := &GdocRender{
 		Source:      gdocURL,
 		Path:        htmlPath,
 		BuildFolder: buildFolder,
 		Domain: domain,
 		Layout: layout,
+		preview: preview,
 	}
 
-	resp, err := apiclients.GetGdocHtml(clientSecretPath, gr.ID())
-	if err != nil {
-		return nil, err
-	}
-
-	defer resp.Body.Close()
-	doc, err := goquery.NewDocumentFromResponse(resp)
+	doc, modified, cleanup, err := gr.openGdoc(clientSecretPath)
 	if err != nil {
 		return nil, err
 	}
+	defer cleanup()
+	gr.styles = parseStyles(doc.Find("style").Text())
 	body := doc.Find("body")
 
 	if err = gr.parseMetadata(body); err != nil {
 		return nil, err
 	}
 
+	times := &apiclients.FileTimes{Created: gr.Metadata.Published, Modified: modified}
+	if len(gr.sourceDir) == 0 {
+		if times, err = apiclients.GetFileTimes(clientSecretPath, gr.ID()); err != nil {
+			return nil, err
+		}
+	}
+	gr.Metadata.useFileTimes(times)
+	gr.fillLesson()
+
+	removeMetadataTable(body)
 	if err = gr.renderArticleBody(body); err != nil {
 		return nil, err
 	}
+	return gr, err
+}
+
+// Opens the gdoc's html, downloading it from drive unless it is a local
+// export, and gives when a local export was last modified. The returned
+// cleanup removes the folder a zipped export is extracted to, which its
+// images are read from until the gdoc is rendered.
+func (gr *GdocRender) openGdoc(clientSecretPath string) (*goquery.Document, time.Time, func(), error) {
+	cleanup := func() {}
+	if !isLocalExport(gr.Source) {
+		doc, err := gr.fetchGdoc(clientSecretPath)
+		return doc, time.Time{}, cleanup, err
+	}
+
+	exportPath := gr.Source
+	if strings.ToLower(filepath.Ext(exportPath)) == zipExt {
+		scratchFolder, err := ioutil.TempDir("", "gdoc-")
+		if err != nil {
+			return nil, time.Time{}, cleanup, err
+		}
+		cleanup = func() { os.RemoveAll(scratchFolder) }
+		if exportPath, err = unzipExport(gr.Source, scratchFolder); err != nil {
+			cleanup()
+			return nil, time.Time{}, func() {}, err
+		}
+	}
+	doc, modified, err := gr.openExport(exportPath)
+	if err != nil {
+		cleanup()
+		return nil, time.Time{}, func() {}, err
+	}
+	return doc, modified, cleanup, nil
+}
 
-	if err = gr.write(); err != nil {
+// Downloads the gdoc's html export from drive
+func (gr *GdocRender) fetchGdoc(clientSecretPath string) (*goquery.Document, error) {
+	resp, err := apiclients.GetGdocHtml(clientSecretPath, gr.ID())
+	if err != nil {
 		return nil, err
 	}
 
-	return gr, err
+	defer resp.Body.Close()
+	return goquery.NewDocumentFromResponse(resp)
+}
+
+// Writes the article's pages out, even if it is a draft. Links to docs found
+// to be drafts since the article was rendered are removed first.
+func (gr *GdocRender) Write() error {
+	gr.unlinkDrafts()
+	return gr.write()
 }
 
 // Writes the article out
 func (gr GdocRender) write() error {
+	pg := gr.page()
+	if len(gr.Steps) == 0 {
+		return templates.RenderPage(pg, gr.BuildFolder)
+	}
+
+	var steps []*templates.Step
+	for _, step := range gr.Steps {
+		steps = append(steps, &templates.Step{
+			Number:   step.Number,
+			Title:    step.Title,
+			FilePath: step.Path,
+			Duration: step.Duration,
+		})
+	}
+	for _, step := range gr.Steps {
+		stepPg := *pg
+		stepPg.Title = fmt.Sprintf("%s - %d. %s", pg.Title, step.Number, step.Title)
+		stepPg.ArticleHTML = templates.RenderHTML(step.HTML)
+		stepPg.FilePath = step.Path
+		stepPg.Steps, stepPg.StepNumber = steps, step.Number
+		stepPg.AMPComponents = step.AMPComponents
+		if step.Number != 1 {
+			// only the first step stands for the whole lesson
+			stepPg.Canonical = ""
+		}
+		if pg.TOC != nil {
+			stepPg.TOC = step.TOC
+		}
+		stepPg.StructuredData = gr.structuredData(&stepPg)
+		if err := templates.RenderPage(&stepPg, gr.BuildFolder); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Builds the metadata of the page showing the whole article
+func (gr GdocRender) page() *templates.PageMetadata {
+	description := gr.Metadata.Description
+	if len(description) == 0 {
+		description = gr.Metadata.Summary
+	}
+	pageType := templates.TypeWebsite
+	if gr.Lesson() != nil {
+		pageType = templates.TypeArticle
+	}
+
 	pg := &templates.PageMetadata{
 		Title: gr.Metadata.Title,
+		Description: description,
+		Author: gr.Metadata.Author,
+		Image: gr.Metadata.Image,
+		Type: pageType,
+		Canonical: gr.Metadata.Canonical,
 		ArticleHTML: templates.RenderHTML(gr.ArticleHTML),
 		FilePath: gr.Path,
 		Domain: gr.Domain,
-		Social: &templates.Social{
-			Headline: gr.Metadata.Title,
-			DatePublished: time.Now(),
-			Image: []string{gr.Metadata.Image},
-		},
+		Updated: gr.Metadata.Updated,
+		Deprecated: gr.Metadata.Status == StatusDeprecated,
 		Layout: gr.Layout,
+		AMPComponents: gr.AMPComponents,
+	}
+
+	switch strings.ToLower(gr.Metadata.TOC) {
+	case templates.TOCTop:
+		pg.TOC, pg.TOCPosition = gr.TOC, templates.TOCTop
+	case templates.TOCSideBar, "sidebar":
+		pg.TOC, pg.TOCPosition = gr.TOC, templates.TOCSideBar
+	}
+	pg.StructuredData = gr.structuredData(pg)
+	return pg
+}
+
+// Paths of the pages the gdoc is written to
+func (gr GdocRender) Pages() []string {
+	if len(gr.Steps) == 0 {
+		return []string{gr.Path}
+	}
+	var pages []string
+	for _, step := range gr.Steps {
+		pages = append(pages, step.Path)
+	}
+	return pages
+}
+
+// Whether the gdoc's metadata marks it as a draft, which is not published
+func (gr GdocRender) IsDraft() bool {
+	return gr.Metadata.Status == StatusDraft
+}
+
+// Gives what was built from the gdoc. The pages of drafts are given, though
+// they are not written out, so that their problems are still reported.
+func (gr GdocRender) Output() *Output {
+	return &Output{
+		Source:   gr.Source,
+		Title:    gr.Metadata.Title,
+		Pages:    gr.Pages(),
+		AMP:      true,
+		Updated:  gr.Metadata.Updated,
+		Draft:    gr.IsDraft(),
+		Warnings: gr.Warnings,
+		Comments: gr.Comments,
+	}
+}
+
+// Gives an image's contents and mime type. Images of local sources may be
+// given relative to the source's file, but not outside of its folder.
+func (gr GdocRender) fetchImage(imageUrl string) ([]byte, string, error) {
+	if u, err := url.Parse(imageUrl); err == nil && len(u.Scheme) == 0 && len(gr.sourceDir) != 0 {
+		imagePath := filepath.Join(gr.sourceDir, filepath.FromSlash(u.Path))
+		if rel, err := filepath.Rel(gr.sourceDir, imagePath); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, "", fmt.Errorf("image %s is outside of %s", imageUrl, gr.sourceDir)
+		}
+		b, err := ioutil.ReadFile(imagePath)
+		return b, mime.TypeByExtension(filepath.Ext(imagePath)), err
+	}
+
+	resp, err := http.Get(imageUrl)
+	if err != nil {
+		return nil, "", err
 	}
 
-	return templates.RenderPage(pg, gr.BuildFolder)
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	return b, resp.Header.Get("content-type"), err
 }
 
 // Gives the gdoc's ID from parsing source url.
 func (gr GdocRender) ID() string {
-	const s = "/document/d/"
-	gdocId := gr.Source
-	if i := strings.Index(gdocId, s); i >= 0 {
-		gdocId = gdocId[i+len(s):]
+	return gdocID(gr.Source)
+}
+
+// Gives the layout's lesson rendered from this gdoc, or nil if the gdoc is
+// not a lesson.
+func (gr GdocRender) Lesson() *layout.Lesson {
+	if gr.Layout == nil {
+		return nil
+	}
+	for _, lesson := range gr.Layout.Lessons {
+		if len(lesson.SourceGDoc) != 0 && gdocID(lesson.SourceGDoc) == gr.ID() {
+			return lesson
+		}
+		if len(lesson.SourceMarkdown) != 0 && len(gr.contentDir) != 0 && markdownKey(lesson.SourceMarkdown) == markdownKey(gr.Source) {
+			return lesson
+		}
 	}
-	if i := strings.IndexRune(gdocId, '/'); i > 0 {
-		gdocId = gdocId[:i]
+	return nil
+}
+
+// Fills in the layout's lesson, if the gdoc is one, with the details only its
+// metadata knows
+func (gr GdocRender) fillLesson() {
+	if lesson := gr.Lesson(); lesson != nil {
+		lesson.Author = gr.Metadata.Author
+		lesson.Duration = gr.Metadata.Duration
+		lesson.Updated = gr.Metadata.Updated
+		lesson.Draft = gr.IsDraft()
 	}
-	return gdocId
 }
 
 // Parses the document's metadata which will be used for things like social media
 // and meta tags. Metadata is attached to the GdocRender struct.
 func (gr *GdocRender) parseMetadata(body *goquery.Selection) error {
-	metadata := new(GdocMetadata)
+	metadata := &GdocMetadata{Status: StatusPublished}
 	trs := body.Find("table").First().Find("tr")
 	var parseError error
 
@@ -129,33 +391,20 @@ func (gr *GdocRender) parseMetadata(body *goquery.Selection) error {
 			return false
 		}
 
-		columnName := columns.First().Text()
+		columnName := strings.ToUpper(strings.TrimSpace(columns.First().Text()))
 		columnValue := columns.Next().First()
+		value := strings.TrimSpace(columnValue.Text())
 
-		switch strings.ToUpper(columnName) {
-		case "TITLE":
-			metadata.Title = columnValue.Text()
-
-		case "SUMMARY":
-			metadata.Summary = columnValue.Text()
-
-		case "AUTHOR":
-			metadata.Author = columnValue.Text()
-
-		case "IMAGE":
+		if columnName == "IMAGE" {
 			url, ok := columnValue.Find("img").First().Attr("src")
 			if !ok {
 				parseError = errors.New("Image does not have a source: " + columnValue.Text())
 				return false
 			}
-			fname, _, err := gr.downloadImage(url)
-			if err != nil {
-				parseError = err
-				return false
-			}
-			metadata.Image = fname
+			value = url
 		}
-		return true
+		parseError = gr.parseMetadataValue(metadata, columnName, value)
+		return parseError == nil
 	})
 
 	if parseError == nil {
@@ -164,22 +413,77 @@ func (gr *GdocRender) parseMetadata(body *goquery.Selection) error {
 	return parseError
 }
 
+// Sets the metadata of an upper-cased key, such as TITLE, from its value
+func (gr *GdocRender) parseMetadataValue(metadata *GdocMetadata, key string, value string) error {
+	var err error
+	switch key {
+	case "":
+		// blank row
+
+	case "TITLE":
+		metadata.Title = value
+
+	case "SUMMARY":
+		metadata.Summary = value
+
+	case "AUTHOR":
+		metadata.Author = value
+
+	case "TOC":
+		metadata.TOC = value
+
+	case "TAGS":
+		metadata.Tags = parseMetadataTags(value)
+
+	case "DESCRIPTION":
+		metadata.Description = value
+
+	case "PUBLISHED":
+		metadata.Published, err = parseMetadataDate(value)
+
+	case "UPDATED":
+		metadata.Updated, err = parseMetadataDate(value)
+
+	case "CANONICAL":
+		metadata.Canonical, err = parseMetadataCanonical(value)
+
+	case "STATUS":
+		metadata.Status, err = parseMetadataStatus(value)
+
+	case "DURATION":
+		metadata.Duration, err = parseMetadataDuration(value)
+
+	case "LEVEL":
+		metadata.Level, err = parseMetadataLevel(value)
+
+	case "STEPS":
+		metadata.Steps, err = parseMetadataSteps(value)
+
+	case "IMAGE":
+		metadata.Image, _, err = gr.downloadImage(value)
+
+	default:
+		if suggestion, ok := suggestMetadataKey(key); ok {
+			gr.warn("unknown metadata key " + key + ", did you mean " + suggestion + "?")
+		} else {
+			gr.warn("unknown metadata key " + key)
+		}
+	}
+	return err
+}
+
 // downloads an image to the build folder. Returns the saved image's filename.
 // so total path to image is downloadFolder + imageFileName
 func (gr GdocRender) downloadImage(imageUrl string) (string, *image.Rectangle, error) {
-	resp, err := http.Get(imageUrl)
+	b, mimeType, err := gr.fetchImage(imageUrl)
 	if err != nil {
 		return "", nil, err
 	}
 
-	defer resp.Body.Close()
-	b, err := ioutil.ReadAll(resp.Body)
-
 	// generate a unique filename
 	fname := "/img/" + uuid.NewV4().String()
 
 	// parse the file extension for the image if possible
-	mimeType := resp.Header.Get("content-type")
 	if len(mimeType) != 0 {
 		fname += "." + strings.Replace(mimeType, "image/", "", 1)
 	}
@@ -208,50 +512,80 @@ func (gr GdocRender) downloadImage(imageUrl string) (string, *image.Rectangle, e
 	return fname, &bounds, nil
 }
 
+// Removes all nodes up to and including the first table which stores the
+// metadata, so that only article content is left to render
+func removeMetadataTable(body *goquery.Selection) {
+	body.Children().EachWithBreak(func(i int, ns *goquery.Selection) bool {
+		isTable := ns.Get(0).DataAtom == atom.Table
+		ns.Remove()
+		return !isTable
+	})
+}
+
 // Cleans up the document's html to only include the relavent styling
 func (gr *GdocRender) renderArticleBody(body *goquery.Selection) error {
-	seenMetadataTable := false
+	gr.docHrefs = publishedDocs(gr.Layout)
+
+	// Restructure the gdoc's dom before its styling is stripped
+	gr.renderComments(body.Get(0))
+	gr.renderCodeBlocks(body.Get(0))
+	gr.renderLists(body.Get(0))
+	gr.renderTables(body.Get(0))
+	gr.renderFootnotes(body.Get(0))
+	gr.renderHeadings(body.Get(0))
+	if gr.Metadata != nil && gr.Metadata.Steps == StepsPageBreak {
+		gr.markPageBreaks(body.Get(0))
+	}
 
 	// Go through root elements one by one and re-style them to correct DOM
 	var cleaningError error
-	articleHTML := ""
 	body.Children().EachWithBreak(func(i int, ns *goquery.Selection) bool {
-		n := ns.Get(0)
-
-		// Ignore all nodes until after the first table which stores the metadata
-		if !seenMetadataTable {
-			if n.DataAtom == atom.Table {
-				seenMetadataTable = true
-			}
-			return true
-		}
-
 		// Clean children of this node
-		n, err := gr.cleanNode(n)
-		if err != nil {
+		if _, err := gr.cleanNode(ns.Get(0)); err != nil {
 			cleaningError = err
 			return false
 		}
+		return true
+	})
+	if cleaningError != nil {
+		return cleaningError
+	}
+
+	// Only allowlisted html is marked as safe to render
+	sanitizeChildren(body.Get(0), gr.warn)
+
+	gr.body = body.Get(0)
+	gr.ArticleHTML = renderElements(gr.body)
+	if gr.Metadata != nil && gr.Metadata.Steps != StepsNone && !gr.preview {
+		gr.splitSteps(gr.body)
+	}
+	return nil
+}
 
-		// // if resulting node is not empty after cleaning, render it out
-		if n != nil {
+// Renders the element children of the node to html
+func renderElements(n *html.Node) string {
+	articleHTML := ""
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode {
 			buf := new(bytes.Buffer)
-			html.Render(buf, n)
+			html.Render(buf, c)
 			articleHTML += buf.String()
 		}
-
-		return true
-	})
-	gr.ArticleHTML = articleHTML
-	return cleaningError
+	}
+	return articleHTML
 }
 
 // Cleans up a given node
-func (gr GdocRender) cleanNode(n *html.Node) (*html.Node, error) {
+func (gr *GdocRender) cleanNode(n *html.Node) (*html.Node, error) {
 	if n == nil {
 		return nil, nil
 	}
 
+	// nodes built by the renderer are already clean
+	if gr.verbatim[n] {
+		return n, nil
+	}
+
 	// clean up children first
 	c := n.FirstChild
 	for c != nil {
@@ -271,6 +605,11 @@ func (gr GdocRender) cleanNode(n *html.Node) (*html.Node, error) {
 		return nil, nil
 	}
 
+	gr.cleanAttributes(n)
+	for _, attr := range gr.attrs[n] {
+		setNodeAttr(n, attr.Key, attr.Val)
+	}
+
 	// fix html
 	var switchErr error
 	switch n.DataAtom {
@@ -280,20 +619,27 @@ func (gr GdocRender) cleanNode(n *html.Node) (*html.Node, error) {
 		n, switchErr = gr.cleanAtomA(n)
 	case atom.Span:
 		n, switchErr = gr.cleanAtomSpan(n)
+	case atom.P:
+		n, switchErr = gr.cleanAtomP(n)
+	case atom.Table:
+		if kind, ok := calloutKind(n); ok {
+			n, switchErr = gr.cleanAtomCallout(n, kind)
+		} else {
+			n, switchErr = gr.cleanAtomTable(n)
+		}
+	case atom.Td, atom.Th:
+		n, switchErr = gr.cleanAtomCell(n)
 	}
 
 	// handle errors occuring in the switch
 	if switchErr != nil {
 		return nil, switchErr
 	}
-	if n != nil {
-		gr.cleanAttributes(n)
-	}
 	return n, nil
 }
 
 // cleans up a <span> node
-func (gr GdocRender) cleanAtomSpan(n *html.Node) (*html.Node, error) {
+func (gr *GdocRender) cleanAtomSpan(n *html.Node) (*html.Node, error) {
 	// if span only contians text or a single child, replace the span with
 	// the contents of its singular child.
 	if n.FirstChild == n.LastChild {
@@ -305,12 +651,15 @@ func (gr GdocRender) cleanAtomSpan(n *html.Node) (*html.Node, error) {
 		n.Data = newN.Data
 		n.Namespace = newN.Namespace
 		n.Attr = newN.Attr
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			c.Parent = n
+		}
 	}
 	return n, nil
 }
 
 // cleans up a <img> node
-func (gr GdocRender) cleanAtomImg(n *html.Node) (*html.Node, error) {
+func (gr *GdocRender) cleanAtomImg(n *html.Node) (*html.Node, error) {
 	n.DataAtom = 0x0
 	n.Data = "amp-img"
 
@@ -327,7 +676,7 @@ func (gr GdocRender) cleanAtomImg(n *html.Node) (*html.Node, error) {
 }
 
 // cleans up an <a> node.
-func (gr GdocRender) cleanAtomA(n *html.Node) (*html.Node, error) {
+func (gr *GdocRender) cleanAtomA(n *html.Node) (*html.Node, error) {
 	// delete elment if a link to a comment
 	if strings.HasPrefix(nodeAttr(n, "href"), commentPrefix) {
 		n.Parent.RemoveChild(n)
@@ -341,10 +690,63 @@ func (gr GdocRender) cleanAtomA(n *html.Node) (*html.Node, error) {
 	}
 	if q, ok := u.Query()["q"]; ok {
 		setNodeAttr(n, "href", q[0])
+		if u, err = url.Parse(q[0]); err != nil {
+			return nil, err
+		}
+	}
+
+	docID := ""
+	if isDriveURL(u) {
+		docID = gdocID(u.Path + "?" + u.RawQuery)
+	}
+
+	// point links to this gdoc's headings at the generated heading ids
+	isSelfLink := len(u.Host) == 0 && len(u.Path) == 0 ||
+		len(docID) != 0 && docID == gr.ID()
+	if isSelfLink && len(u.Fragment) != 0 {
+		if id, ok := gr.headingAnchor(u.Fragment); ok {
+			setNodeAttr(n, "href", "#"+id)
+			return n, nil
+		}
+	}
+
+	// point links between local markdown files at their published page
+	if len(gr.contentDir) != 0 && len(u.Host) == 0 && strings.HasSuffix(u.Path, markdownExt) {
+		key := markdownKey(path.Join(path.Dir(filepath.ToSlash(gr.Source)), u.Path))
+		if href, ok := gr.docHrefs[key]; ok {
+			setNodeAttr(n, "href", href)
+			gr.docLinks = append(gr.docLinks, &docLink{n, key})
+		} else {
+			gr.warn("links to unpublished markdown " + u.Path)
+		}
+		return n, nil
+	}
+
+	// point links to other gdocs at their published page on this site
+	if len(docID) != 0 {
+		if href, ok := gr.docHrefs[docID]; ok {
+			setNodeAttr(n, "href", href)
+			gr.docLinks = append(gr.docLinks, &docLink{n, docID})
+		} else if strings.HasPrefix(u.Path, "/document/") {
+			gr.warn("links to unpublished gdoc " + u.String())
+		}
 	}
 	return n, nil
 }
 
+// Sets an attribute on a gdoc node which is kept when the node is cleaned
+func (gr *GdocRender) keepAttr(n *html.Node, key string, val string) {
+	if gr.attrs == nil {
+		gr.attrs = make(map[*html.Node][]html.Attribute)
+	}
+	gr.attrs[n] = append(gr.attrs[n], html.Attribute{Key: key, Val: val})
+}
+
+// Records a problem with the gdoc that does not stop it from being rendered
+func (gr *GdocRender) warn(msg string) {
+	gr.Warnings = append(gr.Warnings, msg)
+}
+
 // cleans all stlyes, id, classes, and titles from the node.
 func (gr GdocRender) cleanAttributes(n *html.Node) {
 	delNodeAttr(n, "style")
@@ -389,3 +791,66 @@ func nodeAttr(node *html.Node, name string) string {
 	}
 	return ""
 }
+
+// Finds all descendants of the node matching the atom, in document order.
+func findAll(n *html.Node, a atom.Atom) []*html.Node {
+	var found []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.DataAtom == a {
+			found = append(found, c)
+		}
+		found = append(found, findAll(c, a)...)
+	}
+	return found
+}
+
+// Calls fn on every text node under the node, in document order.
+func walkText(n *html.Node, fn func(*html.Node)) {
+	if n.Type == html.TextNode {
+		fn(n)
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walkText(c, fn)
+	}
+}
+
+// Describes the page to search engines. Lessons are tech articles placed
+// under their category and product, other gdocs are plain web pages.
+func (gr GdocRender) structuredData(pg *templates.PageMetadata) []templates.StructuredData {
+	var published, updated *time.Time
+	if !gr.Metadata.Published.IsZero() {
+		published, updated = &gr.Metadata.Published, &gr.Metadata.Published
+	}
+	if !gr.Metadata.Updated.IsZero() {
+		updated = &gr.Metadata.Updated
+	}
+
+	lesson := gr.Lesson()
+	if lesson == nil {
+		page := templates.NewWebPage(pg)
+		page.DateModified = updated
+		return []templates.StructuredData{
+			page,
+			templates.NewBreadcrumbList(gr.Domain, gr.Metadata.Title, gr.Path),
+		}
+	}
+
+	article := templates.NewTechArticle(pg)
+	article.DatePublished, article.DateModified = published, updated
+	if len(gr.Metadata.Author) != 0 {
+		article.Author = &templates.Person{Type: "Person", Name: gr.Metadata.Author}
+	}
+	article.Keywords = strings.Join(gr.Metadata.Tags, ", ")
+	article.TimeRequired = templates.ISODuration(gr.Metadata.Duration)
+	switch gr.Metadata.Level {
+	case LevelBeginner:
+		article.ProficiencyLevel = "Beginner"
+	case LevelAdvanced:
+		article.ProficiencyLevel = "Expert"
+	}
+	return []templates.StructuredData{
+		article,
+		templates.LessonBreadcrumbs(gr.Domain, lesson),
+	}
+}
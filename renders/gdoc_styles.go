@@ -0,0 +1,80 @@
+package renders
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+var classSelector = regexp.MustCompile(`^\.([\w-]+)$`)
+
+// css declarations keyed by class name, then by property
+type gdocStyles map[string]map[string]string
+
+// Parses the stylesheet of a gdoc export. Gdocs only style elements through
+// single class selectors (.c1, .c2, ...) so all other rules are ignored.
+func parseStyles(css string) gdocStyles {
+	styles := make(gdocStyles)
+	for _, rule := range strings.Split(css, "}") {
+		i := strings.Index(rule, "{")
+		if i < 0 {
+			continue
+		}
+		decls := parseDeclarations(rule[i+1:])
+		for _, selector := range strings.Split(rule[:i], ",") {
+			m := classSelector.FindStringSubmatch(strings.TrimSpace(selector))
+			if m == nil {
+				continue
+			}
+			if styles[m[1]] == nil {
+				styles[m[1]] = make(map[string]string)
+			}
+			for prop, val := range decls {
+				styles[m[1]][prop] = val
+			}
+		}
+	}
+	return styles
+}
+
+// Parses a css declaration block such as "color:#000;font-weight:700"
+func parseDeclarations(block string) map[string]string {
+	decls := make(map[string]string)
+	for _, decl := range strings.Split(block, ";") {
+		i := strings.Index(decl, ":")
+		if i < 0 {
+			continue
+		}
+		prop := strings.ToLower(strings.TrimSpace(decl[:i]))
+		decls[prop] = strings.TrimSpace(decl[i+1:])
+	}
+	return decls
+}
+
+// Gives the value of a css property set on the node, either inline or through
+// one of its classes. An empty string is returned if the property is not set.
+func (gr GdocRender) nodeStyle(n *html.Node, prop string) string {
+	if val, ok := parseDeclarations(nodeAttr(n, "style"))[prop]; ok {
+		return val
+	}
+	for _, class := range strings.Fields(nodeAttr(n, "class")) {
+		if val, ok := gr.styles[class][prop]; ok {
+			return val
+		}
+	}
+	return ""
+}
+
+// Gives the value of a css property set on the node or its closest ancestor.
+func (gr GdocRender) inheritedStyle(n *html.Node, prop string) string {
+	for ; n != nil; n = n.Parent {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		if val := gr.nodeStyle(n, prop); len(val) != 0 {
+			return val
+		}
+	}
+	return ""
+}
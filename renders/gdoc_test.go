@@ -0,0 +1,213 @@
+package renders
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cobookman/gcp-quickstart/layout"
+	"github.com/cobookman/gcp-quickstart/templates"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Parses a fragment of a gdoc's body, so that a single stage of rendering can
+// be run on its nodes
+func parseBody(t *testing.T, fragment string) *html.Node {
+	body := &html.Node{Type: html.ElementNode, DataAtom: atom.Body, Data: "body"}
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range nodes {
+		body.AppendChild(n)
+	}
+	return body
+}
+
+// Renders the children of a node, as they are written into the article
+func renderChildren(t *testing.T, n *html.Node) string {
+	buf := new(bytes.Buffer)
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if err := html.Render(buf, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.String()
+}
+
+func TestGdocID(t *testing.T) {
+	for source, want := range map[string]string{
+		"https://docs.google.com/document/d/1AbC-d_E/edit#heading=h.x": "1AbC-d_E",
+		"https://docs.google.com/document/u/1/d/1AbC-d_E/":             "1AbC-d_E",
+		"https://drive.google.com/open?id=1AbC-d_E":                    "1AbC-d_E",
+		"1AbC-d_E": "1AbC-d_E",
+	} {
+		if id := gdocID(source); id != want {
+			t.Fatalf("gdocID(%s) = %s, want %s", source, id, want)
+		}
+	}
+}
+
+func TestCleanAtomALinksToPublishedGdocs(t *testing.T) {
+	gr := &GdocRender{
+		Source: "https://docs.google.com/document/d/self/edit",
+		Layout: &layout.Layout{
+			Lessons: []*layout.Lesson{{
+				SourceGDoc: "https://docs.google.com/document/d/lesson/edit",
+				Href:       "/compute/gce/intro/index.html",
+			}},
+			Others: []*layout.Other{{URL: "/about.html", SourceGDoc: "other"}},
+		},
+	}
+	gr.docHrefs = publishedDocs(gr.Layout)
+	body := parseBody(t,
+		`<a href="https://www.google.com/url?q=https://docs.google.com/document/d/lesson/edit&amp;sa=D">lesson</a>`+
+			`<a href="https://docs.google.com/document/d/other/edit#heading=h.abc">other</a>`+
+			`<a href="https://docs.google.com/document/d/draft/edit">draft</a>`+
+			`<a href="https://docs.google.com/spreadsheets/d/sheet/edit">sheet</a>`)
+	for _, a := range findAll(body, atom.A) {
+		if _, err := gr.cleanAtomA(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := `<a href="/compute/gce/intro/index.html">lesson</a>` +
+		`<a href="/about.html">other</a>` +
+		`<a href="https://docs.google.com/document/d/draft/edit">draft</a>` +
+		`<a href="https://docs.google.com/spreadsheets/d/sheet/edit">sheet</a>`
+	if got := renderChildren(t, body); got != want {
+		t.Fatalf("got %s\nwant %s", got, want)
+	}
+	if len(gr.Warnings) != 1 || !strings.Contains(gr.Warnings[0], "document/d/draft") {
+		t.Fatalf("expected a warning for the unpublished gdoc only, got %v", gr.Warnings)
+	}
+}
+
+func TestUnlinkDrafts(t *testing.T) {
+	lesson := &layout.Lesson{SourceGDoc: "https://docs.google.com/document/d/lesson/edit", Href: "/compute/gce/intro/index.html"}
+	later := &layout.Lesson{SourceGDoc: "https://docs.google.com/document/d/later/edit", Href: "/compute/gce/later/index.html"}
+	gr := &GdocRender{
+		Source: "https://docs.google.com/document/d/self/edit",
+		Layout: &layout.Layout{Lessons: []*layout.Lesson{lesson, later}},
+	}
+	gr.docHrefs = publishedDocs(gr.Layout)
+	gr.body = parseBody(t,
+		`<p><a href="https://docs.google.com/document/d/lesson/edit">lesson</a> and `+
+			`<a href="https://docs.google.com/document/d/later/edit">later</a></p>`)
+	for _, a := range findAll(gr.body, atom.A) {
+		if _, err := gr.cleanAtomA(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// found to be a draft once rendered, after this gdoc was
+	later.Draft = true
+	gr.unlinkDrafts()
+	want := `<p><a href="/compute/gce/intro/index.html">lesson</a> and later</p>`
+	if gr.ArticleHTML != want {
+		t.Fatalf("got %s\nwant %s", gr.ArticleHTML, want)
+	}
+	if len(gr.Warnings) != 1 || !strings.Contains(gr.Warnings[0], later.Href) {
+		t.Fatalf("expected a warning for the link to the draft, got %v", gr.Warnings)
+	}
+}
+
+func TestStructuredDataForLessons(t *testing.T) {
+	category := &layout.Category{ID: "compute", Name: "Compute"}
+	product := &layout.Product{Category: category, ID: "gce", Name: "Compute Engine"}
+	lesson := &layout.Lesson{
+		Product:    product,
+		Name:       "Intro",
+		SourceGDoc: "https://docs.google.com/document/d/lesson/edit",
+		Href:       "/compute/gce/intro/index.html",
+	}
+	gr := GdocRender{
+		Source: lesson.SourceGDoc,
+		Path:   lesson.Href,
+		Domain: "https://example.com",
+		Layout: &layout.Layout{Lessons: []*layout.Lesson{lesson}},
+		Metadata: &GdocMetadata{
+			Title:     "Intro",
+			Author:    "Ada",
+			Tags:      []string{"vm", "linux"},
+			Published: time.Date(2018, 1, 2, 0, 0, 0, 0, time.UTC),
+			Duration:  90 * time.Minute,
+			Level:     LevelBeginner,
+		},
+	}
+	pg := &templates.PageMetadata{Title: "Intro", FilePath: gr.Path, Domain: gr.Domain}
+
+	data := gr.structuredData(pg)
+	for _, d := range data {
+		if err := d.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	article, ok := data[0].(*templates.TechArticle)
+	if !ok {
+		t.Fatalf("expected a TechArticle, got %T", data[0])
+	}
+	if article.DateModified == nil || !article.DateModified.Equal(gr.Metadata.Published) {
+		t.Fatalf("expected the modified date to fall back to the published date, got %v", article.DateModified)
+	}
+	if article.Author.Name != "Ada" || article.Keywords != "vm, linux" ||
+		article.TimeRequired != "PT1H30M" || article.ProficiencyLevel != "Beginner" {
+		t.Fatalf("unexpected article %+v", article)
+	}
+	crumbs := data[1].(*templates.BreadcrumbList).ItemListElement
+	if len(crumbs) != 4 || crumbs[2].Item != "https://example.com/compute/gce/index.html" ||
+		crumbs[3].Item != "https://example.com/compute/gce/intro/index.html" {
+		t.Fatalf("unexpected breadcrumbs %+v", crumbs)
+	}
+}
+
+func TestDraftsAreNotPublished(t *testing.T) {
+	lesson := &layout.Lesson{SourceGDoc: "https://docs.google.com/document/d/draft/edit", Href: "/compute/gce/draft/index.html"}
+	gr := GdocRender{
+		Source:   lesson.SourceGDoc,
+		Path:     lesson.Href,
+		Layout:   &layout.Layout{Lessons: []*layout.Lesson{lesson}},
+		Metadata: &GdocMetadata{Title: "Draft", Status: StatusDraft},
+	}
+	gr.fillLesson()
+
+	out := gr.Output()
+	if !out.Draft || len(out.Pages) != 1 || out.Pages[0] != lesson.Href {
+		t.Fatalf("expected a draft reported under its href, got %+v", out)
+	}
+	if !lesson.Draft {
+		t.Fatal("expected the lesson to be marked as a draft")
+	}
+
+	gr.Metadata.Status = StatusPublished
+	if out := gr.Output(); out.Draft || len(out.Pages) != 1 || !out.AMP {
+		t.Fatalf("expected a published AMP page, got %+v", out)
+	}
+}
+
+func TestDeprecatedPages(t *testing.T) {
+	gr := GdocRender{Path: "/about/index.html", Metadata: &GdocMetadata{Title: "Old", Status: StatusDeprecated}}
+	if !gr.page().Deprecated {
+		t.Fatal("expected the page to be marked as deprecated")
+	}
+	gr.Metadata.Status = StatusPublished
+	if gr.page().Deprecated {
+		t.Fatal("expected a published page not to be deprecated")
+	}
+}
+
+func TestPublishedDocsLeavesOutDrafts(t *testing.T) {
+	l := &layout.Layout{
+		Lessons: []*layout.Lesson{
+			{SourceGDoc: "https://docs.google.com/document/d/lesson/edit", Href: "/compute/gce/intro/index.html"},
+			{SourceGDoc: "https://docs.google.com/document/d/draft/edit", Href: "/compute/gce/draft/index.html", Draft: true},
+		},
+		Others: []*layout.Other{{URL: "/about.html", SourceGDoc: "other", Draft: true}},
+	}
+	docs := publishedDocs(l)
+	if len(docs) != 1 || docs["lesson"] != "/compute/gce/intro/index.html" {
+		t.Fatalf("expected only the published lesson, got %v", docs)
+	}
+}
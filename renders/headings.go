@@ -0,0 +1,86 @@
+package renders
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/cobookman/gcp-quickstart/templates"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	headingLinkPrefix = "heading="
+)
+
+var headingLevels = map[atom.Atom]int{
+	atom.H1: 1, atom.H2: 2, atom.H3: 3, atom.H4: 4, atom.H5: 5, atom.H6: 6,
+}
+
+// Gives every heading a stable id generated from its text, and builds the
+// table of contents from the h2 and h3 headings. The gdoc's own heading ids
+// are remembered so that links to them can be rewritten to the new ids.
+func (gr *GdocRender) renderHeadings(body *html.Node) {
+	gr.headingIDs = make(map[string]string)
+	gr.TOC = nil
+	taken := make(map[string]bool)
+
+	for n := body.FirstChild; n != nil; n = n.NextSibling {
+		level, ok := headingLevels[n.DataAtom]
+		if !ok {
+			continue
+		}
+		text := strings.Join(strings.Fields(codeText(n)), " ")
+		if len(text) == 0 {
+			continue
+		}
+
+		slug := slugify(text)
+		id := slug
+		for i := 1; taken[id]; i++ {
+			id = slug + "-" + strconv.Itoa(i)
+		}
+		taken[id] = true
+
+		gr.keepAttr(n, "id", id)
+		if gdocID := nodeAttr(n, "id"); len(gdocID) != 0 {
+			gr.headingIDs[gdocID] = id
+		}
+		if level == 2 || level == 3 {
+			gr.TOC = append(gr.TOC, &templates.TOCEntry{
+				Level: level,
+				ID:    id,
+				Text:  text,
+			})
+		}
+	}
+}
+
+// Gives the id of the heading a gdoc link fragment such as "#heading=h.xyz"
+// or "#h.xyz" points to.
+func (gr GdocRender) headingAnchor(fragment string) (string, bool) {
+	id, ok := gr.headingIDs[strings.TrimPrefix(fragment, headingLinkPrefix)]
+	return id, ok
+}
+
+// Turns text into a lowercase, dash separated id such as "how-can-i-contribute"
+func slugify(text string) string {
+	slug := ""
+	dash := false
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && len(slug) != 0 {
+				slug += "-"
+			}
+			slug += string(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	if len(slug) == 0 {
+		return "section"
+	}
+	return slug
+}
@@ -0,0 +1,34 @@
+package renders
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderHeadings(t *testing.T) {
+	gr := renderFixture(t, "",
+		`<h2 id="h.abc"><span>Getting Started</span></h2>`+
+			`<p><a href="#heading=h.xyz">jump</a> <a href="#h.abc">top</a></p>`+
+			`<h3 id="h.def"><span>Install the SDK!</span></h3>`+
+			`<h2 id="h.xyz"><span>Getting started</span></h2>`+
+			`<h4 id="h.ghi"><span>Details</span></h4>`)
+
+	for _, want := range []string{
+		`<h2 id="getting-started">`,
+		`<h3 id="install-the-sdk">`,
+		`<h2 id="getting-started-1">`,
+		`<a href="#getting-started-1">jump</a>`,
+		`<a href="#getting-started">top</a>`,
+	} {
+		if !strings.Contains(gr.ArticleHTML, want) {
+			t.Fatalf("missing %s in %s", want, gr.ArticleHTML)
+		}
+	}
+
+	if len(gr.TOC) != 3 {
+		t.Fatalf("expected the h2 and h3 headings in the toc, got %d entries", len(gr.TOC))
+	}
+	if gr.TOC[1].Level != 3 || gr.TOC[1].Text != "Install the SDK!" {
+		t.Fatalf("unexpected toc entry %+v", gr.TOC[1])
+	}
+}
@@ -0,0 +1,35 @@
+package renders
+
+import (
+  "bytes"
+  "github.com/cobookman/gcp-quickstart/templates"
+  "github.com/cobookman/gcp-quickstart/layout"
+)
+
+// Renders the site's home page, which lists the categories along with the
+// featured and recently updated lessons. Lessons must be built first, for
+// their update times to be known.
+func RenderHome(layout *layout.Layout, buildFolder string, domain string) error {
+  buf := new(bytes.Buffer)
+  if err := templates.Templates().ExecuteTemplate(buf, "home", layout); err != nil {
+    return err
+  }
+
+  pg := &templates.PageMetadata{
+    Title: "GCP Quickstarts",
+    Description: "An open quickstart guide to Google Cloud Platform",
+    Type: templates.TypeWebsite,
+    FilePath: "/index.html",
+    Domain: domain,
+    Updated: layout.LastUpdated(),
+    ArticleHTML: templates.RenderHTML(buf.String()),
+    Layout: layout,
+  }
+  collection := templates.NewCollectionPage(pg)
+  if !pg.Updated.IsZero() {
+    collection.DateModified = &pg.Updated
+  }
+  pg.StructuredData = []templates.StructuredData{collection}
+
+  return templates.RenderPage(pg, buildFolder)
+}
@@ -0,0 +1,43 @@
+package renders
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/cobookman/gcp-quickstart/layout"
+)
+
+func TestRenderHomeImages(t *testing.T) {
+	defer inRepoRoot(t)()
+	dir, err := ioutil.TempDir("", "home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := RenderHome(&layout.Layout{}, dir, "https://example.com"); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcs := regexp.MustCompile(`<amp-img[^>]* src="(/[^"]*)"`).FindAllStringSubmatch(string(b), -1)
+	if len(srcs) == 0 {
+		t.Fatal("expected the home page to show an image")
+	}
+	for _, src := range srcs {
+		// the build copies statics/ to build/statics/
+		if !strings.HasPrefix(src[1], "/statics/") {
+			t.Fatalf("%s is not served from the built statics", src[1])
+		}
+		if _, err := os.Stat(filepath.FromSlash(strings.TrimPrefix(src[1], "/"))); err != nil {
+			t.Fatalf("%s is missing from the built output: %v", src[1], err)
+		}
+	}
+}
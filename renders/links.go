@@ -0,0 +1,102 @@
+package renders
+
+import (
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/cobookman/gcp-quickstart/layout"
+	"golang.org/x/net/html"
+)
+
+// matches the id in urls such as docs.google.com/document/d/<id>/edit
+var driveIDPattern = regexp.MustCompile(`/d/([\w-]+)`)
+
+// Gives the drive id of a gdoc from its url. Sources which are not urls are
+// assumed to already be an id.
+func gdocID(source string) string {
+	if m := driveIDPattern.FindStringSubmatch(source); m != nil {
+		return m[1]
+	}
+	if u, err := url.Parse(source); err == nil {
+		if id := u.Query().Get("id"); len(id) != 0 {
+			return id
+		}
+	}
+	if i := strings.IndexRune(source, '/'); i > 0 {
+		source = source[:i]
+	}
+	return source
+}
+
+// True if the url points to a file in google drive or docs
+func isDriveURL(u *url.URL) bool {
+	return u.Host == "docs.google.com" || u.Host == "drive.google.com"
+}
+
+// Markdown sources are keyed by their cleaned path within the content folder
+func markdownKey(source string) string {
+	return path.Clean("/" + filepath.ToSlash(source))
+}
+
+// Maps the id of every gdoc published by the layout to its page on this site.
+// Drafts are left out, as their pages are not written, so that links to them
+// are warned about as links to any other unpublished gdoc are.
+func publishedDocs(l *layout.Layout) map[string]string {
+	docs := make(map[string]string)
+	if l == nil {
+		return docs
+	}
+	for _, lesson := range l.Lessons {
+		if lesson.Draft {
+			continue
+		}
+		if len(lesson.SourceGDoc) != 0 {
+			docs[gdocID(lesson.SourceGDoc)] = lesson.Href
+		}
+		if len(lesson.SourceClaat) != 0 {
+			docs[gdocID(lesson.SourceClaat)] = lesson.Href
+		}
+		if len(lesson.SourceMarkdown) != 0 {
+			docs[markdownKey(lesson.SourceMarkdown)] = lesson.Href
+		}
+	}
+	for _, other := range l.Others {
+		if other.Draft {
+			continue
+		}
+		docs[gdocID(other.SourceGDoc)] = other.URL
+	}
+	return docs
+}
+
+// A link pointed at the page of another doc, keyed as publishedDocs keys it
+type docLink struct {
+	node *html.Node
+	key  string
+}
+
+// Removes the links to docs which have been found to be drafts since the
+// article was rendered, keeping their text. Every doc is rendered before any
+// is written, so by then all drafts are known.
+func (gr *GdocRender) unlinkDrafts() {
+	docs := publishedDocs(gr.Layout)
+	unlinked := false
+	for _, link := range gr.docLinks {
+		if _, ok := docs[link.key]; ok || link.node.Parent == nil {
+			continue
+		}
+		gr.warn("links to draft " + nodeAttr(link.node, "href"))
+		unwrapNode(link.node)
+		unlinked = true
+	}
+	if !unlinked {
+		return
+	}
+	gr.ArticleHTML = renderElements(gr.body)
+	if len(gr.Steps) != 0 {
+		gr.splitSteps(gr.body)
+	}
+}
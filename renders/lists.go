@@ -0,0 +1,126 @@
+package renders
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// gdoc list classes encode the list's id and nesting depth, as in lst-kix_ab1-0
+var listClassPattern = regexp.MustCompile(`^lst-kix_(.+)-(\d+)$`)
+
+// a list being rebuilt, which later flat gdoc lists may be merged into
+type openList struct {
+	node  *html.Node
+	id    string
+	level int
+}
+
+// Gdoc exports every level of a nested list as its own flat <ul> or <ol>,
+// with the depth and numbering kept in lst-kix classes. Rebuilds these into
+// nested lists, keeping the numbering of ordered lists through "start".
+func (gr *GdocRender) renderLists(body *html.Node) {
+	// items seen so far per list id and level, for numbering
+	counters := make(map[string]int)
+	var stack []*openList
+
+	var next *html.Node
+	for n := body.FirstChild; n != nil; n = next {
+		next = n.NextSibling
+		id, level, ok := gdocListClass(n)
+		if !ok {
+			if n.Type == html.ElementNode {
+				stack = nil
+			}
+			continue
+		}
+
+		key := id + "-" + strconv.Itoa(level)
+		if start, err := strconv.Atoi(nodeAttr(n, "start")); err == nil {
+			counters[key] = start - 1
+		} else if hasClass(n, "start") {
+			counters[key] = 0
+		}
+
+		// close the lists nested deeper than this one
+		for len(stack) != 0 && stack[len(stack)-1].level > level {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) != 0 {
+			top := stack[len(stack)-1]
+			if top.level == level && (top.id != id || top.node.DataAtom != n.DataAtom) {
+				stack = stack[:len(stack)-1]
+			}
+		}
+
+		var target *html.Node
+		if len(stack) != 0 && stack[len(stack)-1].level == level {
+			target = stack[len(stack)-1].node
+		} else {
+			target = &html.Node{Type: html.ElementNode, DataAtom: n.DataAtom, Data: n.Data}
+			if n.DataAtom == atom.Ol && counters[key] != 0 {
+				setNodeAttr(target, "start", strconv.Itoa(counters[key]+1))
+			}
+			if len(stack) != 0 {
+				parent := stack[len(stack)-1].node
+				if parent.LastChild == nil || parent.LastChild.DataAtom != atom.Li {
+					parent.AppendChild(&html.Node{Type: html.ElementNode, DataAtom: atom.Li, Data: "li"})
+				}
+				parent.LastChild.AppendChild(target)
+			} else {
+				body.InsertBefore(target, n)
+			}
+			stack = append(stack, &openList{node: target, id: id, level: level})
+		}
+
+		for li := n.FirstChild; li != nil; li = n.FirstChild {
+			n.RemoveChild(li)
+			if li.DataAtom == atom.Li {
+				counters[key]++
+				// numbering of nested levels restarts under every item
+				for k := range counters {
+					if l, ok := listLevel(k, id); ok && l > level {
+						delete(counters, k)
+					}
+				}
+			}
+			target.AppendChild(li)
+		}
+		body.RemoveChild(n)
+	}
+}
+
+// Gives the id and nesting level of a gdoc list element
+func gdocListClass(n *html.Node) (string, int, bool) {
+	if n.DataAtom != atom.Ul && n.DataAtom != atom.Ol {
+		return "", 0, false
+	}
+	for _, class := range strings.Fields(nodeAttr(n, "class")) {
+		if m := listClassPattern.FindStringSubmatch(class); m != nil {
+			level, _ := strconv.Atoi(m[2])
+			return m[1], level, true
+		}
+	}
+	return "", 0, false
+}
+
+// Gives the level of a list counter key if it belongs to the list id
+func listLevel(key string, id string) (int, bool) {
+	if !strings.HasPrefix(key, id+"-") {
+		return 0, false
+	}
+	level, err := strconv.Atoi(strings.TrimPrefix(key, id+"-"))
+	return level, err == nil
+}
+
+func hasClass(n *html.Node, class string) bool {
+	for _, c := range strings.Fields(nodeAttr(n, "class")) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
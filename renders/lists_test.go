@@ -0,0 +1,51 @@
+package renders
+
+import (
+	"testing"
+)
+
+func TestRenderListsNested(t *testing.T) {
+	body := parseBody(t,
+		`<ul class="c1 lst-kix_a-0 start"><li>One</li></ul>`+
+			`<ul class="c1 lst-kix_a-1 start"><li>One.One</li><li>One.Two</li></ul>`+
+			`<ul class="c1 lst-kix_a-0"><li>Two</li></ul>`)
+	new(GdocRender).renderLists(body)
+
+	want := `<ul><li>One<ul><li>One.One</li><li>One.Two</li></ul></li><li>Two</li></ul>`
+	if got := renderChildren(t, body); got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func TestRenderListsNumbering(t *testing.T) {
+	body := parseBody(t,
+		`<ol class="lst-kix_b-0 start"><li>One</li></ol>`+
+			`<ol class="lst-kix_b-1 start"><li>One.A</li></ol>`+
+			`<ol class="lst-kix_b-0"><li>Two</li></ol>`+
+			`<ol class="lst-kix_b-1"><li>Two.A</li></ol>`+
+			`<p>Interruption</p>`+
+			`<ol class="lst-kix_b-0"><li>Three</li></ol>`+
+			`<ol class="lst-kix_c-0" start="7"><li>Seven</li></ol>`)
+	new(GdocRender).renderLists(body)
+
+	want := `<ol><li>One<ol><li>One.A</li></ol></li><li>Two<ol><li>Two.A</li></ol></li></ol>` +
+		`<p>Interruption</p>` +
+		`<ol start="3"><li>Three</li></ol>` +
+		`<ol start="7"><li>Seven</li></ol>`
+	if got := renderChildren(t, body); got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func TestGdocListClass(t *testing.T) {
+	body := parseBody(t, `<ul class="c4 lst-kix_x1y-2"></ul><ul class="c4"></ul><p class="lst-kix_x1y-0"></p>`)
+	id, level, ok := gdocListClass(body.FirstChild)
+	if !ok || id != "x1y" || level != 2 {
+		t.Fatalf("got %s %d %v", id, level, ok)
+	}
+	for n := body.FirstChild.NextSibling; n != nil; n = n.NextSibling {
+		if _, _, ok := gdocListClass(n); ok {
+			t.Fatalf("expected <%s> not to be a gdoc list", n.Data)
+		}
+	}
+}
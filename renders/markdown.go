@@ -0,0 +1,168 @@
+package renders
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/cobookman/gcp-quickstart/apiclients"
+	"github.com/cobookman/gcp-quickstart/layout"
+	"github.com/russross/blackfriday"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	markdownExt = ".md"
+	// fences the yaml front matter at the start of a markdown file
+	frontMatterFence = "---"
+)
+
+// Renders a markdown file from the content folder. Its front matter takes the
+// place of a gdoc's metadata table, with the same keys written in lowercase,
+// and the article goes through the same cleaning and page template as gdocs.
+// Images and links to other markdown files are relative to the file. As with
+// gdocs, its pages are left to Write. The file's times on disk are those of
+// its checkout, so it is only shown as updated when its front matter gives an
+// updated date, and otherwise as of its published date.
+func RenderMarkdown(layout *layout.Layout, contentDir string, source string, buildFolder string, htmlPath string, domain string) (*GdocRender, error) {
+	return renderMarkdownFile(layout, contentDir, source, buildFolder, htmlPath, domain, false)
+}
+
+// Renders a markdown file to a single page for previewing, writing it out even
+// if it is a draft. Its images and links are relative to its own folder. As
+// with gdocs, files split into steps are previewed whole.
+func PreviewMarkdown(layout *layout.Layout, markdownPath string, buildFolder string, htmlPath string, domain string) (*GdocRender, error) {
+	if strings.ToLower(filepath.Ext(markdownPath)) != markdownExt {
+		return nil, errors.New(markdownPath + " is not a " + markdownExt + " file")
+	}
+	gr, err := renderMarkdownFile(layout, filepath.Dir(markdownPath), filepath.Base(markdownPath), buildFolder, htmlPath, domain, true)
+	if err != nil {
+		return nil, err
+	}
+	return gr, gr.Write()
+}
+
+func renderMarkdownFile(layout *layout.Layout, contentDir string, source string, buildFolder string, htmlPath string, domain string, preview bool) (*GdocRender, error) {
+	gr := &GdocRender{
+		Source:      source,
+		Path:        htmlPath,
+		BuildFolder: buildFolder,
+		Domain:      domain,
+		Layout:      layout,
+		contentDir:  contentDir,
+		sourceDir:   filepath.Join(contentDir, filepath.Dir(source)),
+		preview:     preview,
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(contentDir, source))
+	if err != nil {
+		return nil, err
+	}
+	if err = gr.renderMarkdown(b); err != nil {
+		return nil, fmt.Errorf("%s: %v", source, err)
+	}
+	return gr, nil
+}
+
+// Renders the markdown file's contents
+func (gr *GdocRender) renderMarkdown(b []byte) error {
+	frontMatter, markdown, err := splitFrontMatter(b)
+	if err != nil {
+		return err
+	}
+	if err = gr.parseFrontMatter(frontMatter); err != nil {
+		return err
+	}
+	// a checked out file's times say nothing of when it was published or
+	// updated, so only the front matter's dates are used
+	gr.Metadata.useFileTimes(&apiclients.FileTimes{Created: gr.Metadata.Published})
+	gr.fillLesson()
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(blackfriday.MarkdownCommon(markdown)))
+	if err != nil {
+		return err
+	}
+	body := doc.Find("body")
+	gr.renderMarkdownCode(body.Get(0))
+	return gr.renderArticleBody(body)
+}
+
+// Splits a markdown file into its yaml front matter, if it has any, and its
+// markdown.
+func splitFrontMatter(b []byte) ([]byte, []byte, error) {
+	text := strings.Replace(string(b), "\r\n", "\n", -1)
+	if !strings.HasPrefix(text, frontMatterFence+"\n") {
+		return nil, []byte(text), nil
+	}
+	rest := text[len(frontMatterFence)+1:]
+	end := strings.Index(rest, "\n"+frontMatterFence+"\n")
+	if end < 0 {
+		if !strings.HasSuffix(rest, "\n"+frontMatterFence) {
+			return nil, nil, errors.New("front matter is not closed by " + frontMatterFence)
+		}
+		return []byte(rest[:len(rest)-len(frontMatterFence)-1]), nil, nil
+	}
+	return []byte(rest[:end]), []byte(rest[end+len(frontMatterFence)+2:]), nil
+}
+
+// Parses yaml front matter into the gdoc metadata, such as
+//
+//	title: Deploying to App Engine
+//	tags: [app engine, python]
+//	duration: 30 min
+//
+// Keys are read in the order the file gives them, so that warnings and keys
+// given twice come out the same on every build.
+func (gr *GdocRender) parseFrontMatter(frontMatter []byte) error {
+	var values yaml.MapSlice
+	if err := yaml.Unmarshal(frontMatter, &values); err != nil {
+		return err
+	}
+
+	metadata := &GdocMetadata{Status: StatusPublished}
+	for _, item := range values {
+		var text string
+		switch v := item.Value.(type) {
+		case []interface{}:
+			var items []string
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+			text = strings.Join(items, ", ")
+		case time.Time:
+			text = v.Format("2006-01-02")
+		case nil:
+		default:
+			text = fmt.Sprint(v)
+		}
+		key := strings.ToUpper(strings.TrimSpace(fmt.Sprint(item.Key)))
+		if err := gr.parseMetadataValue(metadata, key, strings.TrimSpace(text)); err != nil {
+			return err
+		}
+	}
+	gr.Metadata = metadata
+	return nil
+}
+
+// Highlights markdown's fenced code blocks, which are written out as
+// <pre><code class="language-go">, the same way gdoc code blocks are.
+func (gr *GdocRender) renderMarkdownCode(body *html.Node) {
+	for _, pre := range findAll(body, atom.Pre) {
+		code := pre.FirstChild
+		if code == nil || code.DataAtom != atom.Code || code.NextSibling != nil {
+			continue
+		}
+		lang := strings.TrimPrefix(nodeAttr(code, "class"), "language-")
+		highlighted := highlightCode(strings.TrimRight(codeText(code), "\n"), lang)
+		pre.Parent.InsertBefore(highlighted, pre)
+		pre.Parent.RemoveChild(pre)
+		gr.markVerbatim(highlighted)
+	}
+}
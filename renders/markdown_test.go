@@ -0,0 +1,152 @@
+package renders
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cobookman/gcp-quickstart/layout"
+)
+
+func TestSplitFrontMatter(t *testing.T) {
+	frontMatter, markdown, err := splitFrontMatter([]byte("---\r\ntitle: Intro\r\n---\r\n# Hello\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(frontMatter) != "title: Intro" || string(markdown) != "# Hello\n" {
+		t.Fatalf("unexpected split %q %q", frontMatter, markdown)
+	}
+
+	if _, markdown, _ := splitFrontMatter([]byte("# Hello")); string(markdown) != "# Hello" {
+		t.Fatalf("expected the whole file to be markdown, got %q", markdown)
+	}
+	if _, _, err := splitFrontMatter([]byte("---\ntitle: Intro\n# Hello")); err == nil {
+		t.Fatal("expected unclosed front matter to fail")
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	lesson := &layout.Lesson{SourceMarkdown: "compute/intro.md", Href: "/compute/gce/intro/index.html"}
+	other := &layout.Lesson{SourceMarkdown: "compute/setup.md", Href: "/compute/gce/setup/index.html"}
+	gr := &GdocRender{
+		Source:     "compute/intro.md",
+		Layout:     &layout.Layout{Lessons: []*layout.Lesson{lesson, other}},
+		contentDir: "content",
+	}
+	err := gr.renderMarkdown([]byte(`---
+title: Intro to GCE
+tags: [compute, vm]
+published: 2017-01-05
+updated: 2017-06-01
+duration: 30 min
+---
+## Start a VM
+
+Read the [setup](./setup.md) and [missing](missing.md) lessons first.
+
+`+"```go\nfmt.Println(\"hi\")\n```\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	md := gr.Metadata
+	if md.Title != "Intro to GCE" || strings.Join(md.Tags, "|") != "compute|vm" || md.Duration != 30*time.Minute {
+		t.Fatalf("unexpected metadata %+v", md)
+	}
+	if !md.Published.Equal(time.Date(2017, 1, 5, 0, 0, 0, 0, time.UTC)) || !md.Updated.Equal(time.Date(2017, 6, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected dates %v %v", md.Published, md.Updated)
+	}
+	if lesson.Duration != 30*time.Minute {
+		t.Fatal("expected the lesson to be filled in from the front matter")
+	}
+
+	for _, want := range []string{
+		`<h2 id="start-a-vm">Start a VM</h2>`,
+		`<a href="/compute/gce/setup/index.html">setup</a>`,
+		`<a href="missing.md">missing</a>`,
+		`<pre><code class="language-go">`,
+	} {
+		if !strings.Contains(gr.ArticleHTML, want) {
+			t.Fatalf("missing %s in %s", want, gr.ArticleHTML)
+		}
+	}
+	if len(gr.Warnings) != 1 || !strings.Contains(gr.Warnings[0], "missing.md") {
+		t.Fatalf("expected a warning for the unpublished link, got %v", gr.Warnings)
+	}
+}
+
+func TestParseFrontMatterInFileOrder(t *testing.T) {
+	gr := new(GdocRender)
+	err := gr.parseFrontMatter([]byte("zeta: 1\ntitle: First\nsumary: typo\nalpha: 2\ntitle: Second\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gr.Metadata.Title != "Second" {
+		t.Fatalf("expected the last title to win, got %s", gr.Metadata.Title)
+	}
+	want := []string{
+		"unknown metadata key ZETA",
+		"unknown metadata key SUMARY, did you mean SUMMARY?",
+		"unknown metadata key ALPHA",
+	}
+	if strings.Join(gr.Warnings, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("expected warnings in file order, got %v", gr.Warnings)
+	}
+}
+
+
+func TestRenderMarkdownDraft(t *testing.T) {
+	lesson := &layout.Lesson{SourceMarkdown: "draft.md", Href: "/compute/gce/draft/index.html"}
+	gr := &GdocRender{
+		Source:     "draft.md",
+		Layout:     &layout.Layout{Lessons: []*layout.Lesson{lesson}},
+		contentDir: "content",
+	}
+	if err := gr.renderMarkdown([]byte("---\ntitle: Draft\nStatus: Draft\n---\n# Draft\n")); err != nil {
+		t.Fatal(err)
+	}
+	if !gr.IsDraft() || !lesson.Draft {
+		t.Fatal("expected the front matter's status to mark the lesson as a draft")
+	}
+}
+
+func TestRenderMarkdownUpdatedAsPublished(t *testing.T) {
+	gr := &GdocRender{Source: "intro.md", contentDir: "content"}
+	if err := gr.renderMarkdown([]byte("---\npublished: 2017-01-05\n---\n# Intro\n")); err != nil {
+		t.Fatal(err)
+	}
+	if published := time.Date(2017, 1, 5, 0, 0, 0, 0, time.UTC); !gr.Metadata.Updated.Equal(published) {
+		t.Fatalf("expected a file without an updated date to be updated as of publishing, got %v", gr.Metadata.Updated)
+	}
+}
+
+func TestPreviewMarkdownIsNotSplit(t *testing.T) {
+	defer inRepoRoot(t)()
+	dir, err := ioutil.TempDir("", "preview")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	markdownPath := filepath.Join(dir, "intro.md")
+	if err := ioutil.WriteFile(markdownPath, []byte("---\ntitle: Intro\nsteps: h1\n---\n# One\n\nHi\n\n# Two\n\nBye\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	gr, err := PreviewMarkdown(new(layout.Layout), markdownPath, filepath.Join(dir, "preview"), "/index.html", "https://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pages := gr.Pages(); len(pages) != 1 || pages[0] != "/index.html" || len(gr.Steps) != 0 {
+		t.Fatalf("expected a single preview page, got %v", pages)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "preview", "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "step-2.html") || !strings.Contains(string(b), ">Two</h1>") {
+		t.Fatal("expected the whole file on the preview page, without links to step pages")
+	}
+}
@@ -0,0 +1,108 @@
+package renders
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	vimeoIDPattern  = regexp.MustCompile(`^/(\d+)`)
+	slidesIDPattern = regexp.MustCompile(`^/presentation/d/([\w-]+)`)
+)
+
+// Replaces a paragraph holding nothing but a youtube, vimeo or google slides
+// link with the matching AMP embed. The paragraph is kept as is otherwise.
+func (gr *GdocRender) cleanAtomP(n *html.Node) (*html.Node, error) {
+	var link *html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch {
+		case c.Type == html.TextNode && len(strings.TrimSpace(c.Data)) == 0:
+		case c.DataAtom == atom.A && link == nil:
+			link = c
+		default:
+			return n, nil
+		}
+	}
+	if link == nil {
+		return n, nil
+	}
+
+	u, err := url.Parse(nodeAttr(link, "href"))
+	if err != nil {
+		return nil, err
+	}
+	embed := mediaEmbed(u)
+	if embed == nil {
+		return n, nil
+	}
+
+	gr.requireComponent(embed.Data)
+	if n.Parent != nil {
+		n.Parent.InsertBefore(embed, n)
+		n.Parent.RemoveChild(n)
+	}
+	return embed, nil
+}
+
+// Builds the AMP element embedding the media a url points to, or nil if the
+// url is not to an embeddable media.
+func mediaEmbed(u *url.URL) *html.Node {
+	host := strings.TrimPrefix(strings.TrimPrefix(u.Host, "www."), "m.")
+	var attrs []html.Attribute
+	element := ""
+
+	switch {
+	case host == "youtube.com" && u.Path == "/watch" && len(u.Query().Get("v")) != 0:
+		element = "amp-youtube"
+		attrs = []html.Attribute{{Key: "data-videoid", Val: u.Query().Get("v")}}
+
+	case host == "youtube.com" && strings.HasPrefix(u.Path, "/embed/"):
+		element = "amp-youtube"
+		attrs = []html.Attribute{{Key: "data-videoid", Val: strings.TrimPrefix(u.Path, "/embed/")}}
+
+	case host == "youtu.be" && len(u.Path) > 1:
+		element = "amp-youtube"
+		attrs = []html.Attribute{{Key: "data-videoid", Val: strings.TrimPrefix(u.Path, "/")}}
+
+	case host == "vimeo.com" && vimeoIDPattern.MatchString(u.Path):
+		element = "amp-vimeo"
+		attrs = []html.Attribute{{Key: "data-videoid", Val: vimeoIDPattern.FindStringSubmatch(u.Path)[1]}}
+
+	case host == "docs.google.com" && slidesIDPattern.MatchString(u.Path):
+		element = "amp-iframe"
+		id := slidesIDPattern.FindStringSubmatch(u.Path)[1]
+		attrs = []html.Attribute{
+			{Key: "src", Val: "https://docs.google.com/presentation/d/" + id + "/embed?start=false&loop=false"},
+			{Key: "sandbox", Val: "allow-scripts allow-same-origin allow-popups"},
+			{Key: "frameborder", Val: "0"},
+			{Key: "allowfullscreen", Val: ""},
+		}
+
+	default:
+		return nil
+	}
+
+	// 16:9 embeds, scaled to the width of the page
+	attrs = append(attrs,
+		html.Attribute{Key: "layout", Val: "responsive"},
+		html.Attribute{Key: "width", Val: "480"},
+		html.Attribute{Key: "height", Val: "270"})
+	return &html.Node{Type: html.ElementNode, Data: element, Attr: attrs}
+}
+
+// Records that the page needs the script of an AMP component such as
+// "amp-youtube" added to its head.
+func (gr *GdocRender) requireComponent(name string) {
+	for _, component := range gr.AMPComponents {
+		if component == name {
+			return
+		}
+	}
+	gr.AMPComponents = append(gr.AMPComponents, name)
+	sort.Strings(gr.AMPComponents)
+}
@@ -0,0 +1,75 @@
+package renders
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html/atom"
+)
+
+func TestMediaEmbed(t *testing.T) {
+	for link, want := range map[string]string{
+		"https://www.youtube.com/watch?v=dQw4w9WgXcQ":         `<amp-youtube data-videoid="dQw4w9WgXcQ" layout="responsive" width="480" height="270"></amp-youtube>`,
+		"https://m.youtube.com/embed/dQw4w9WgXcQ":             `<amp-youtube data-videoid="dQw4w9WgXcQ"`,
+		"https://youtu.be/dQw4w9WgXcQ":                        `<amp-youtube data-videoid="dQw4w9WgXcQ"`,
+		"https://vimeo.com/76979871":                          `<amp-vimeo data-videoid="76979871"`,
+		"https://docs.google.com/presentation/d/1slides/edit": `<amp-iframe src="https://docs.google.com/presentation/d/1slides/embed?start=false&amp;loop=false"`,
+		"https://www.youtube.com/channel/abc":                 "",
+		"https://docs.google.com/document/d/1doc/edit":        "",
+	} {
+		u, err := url.Parse(link)
+		if err != nil {
+			t.Fatal(err)
+		}
+		embed := mediaEmbed(u)
+		if len(want) == 0 {
+			if embed != nil {
+				t.Fatalf("expected no embed for %s, got <%s>", link, embed.Data)
+			}
+			continue
+		}
+		body := parseBody(t, "")
+		body.AppendChild(embed)
+		if got := renderChildren(t, body); !strings.HasPrefix(got, want) {
+			t.Fatalf("mediaEmbed(%s) = %s, want %s", link, got, want)
+		}
+	}
+}
+
+func TestCleanAtomP(t *testing.T) {
+	body := parseBody(t,
+		`<p> <a href="https://vimeo.com/76979871">https://vimeo.com/76979871</a> </p>`+
+			`<p>Watch <a href="https://youtu.be/dQw4w9WgXcQ">this</a></p>`+
+			`<p><a href="https://youtu.be/a">a</a><a href="https://youtu.be/b">b</a></p>`+
+			`<p><a href="https://example.com">example</a></p>`)
+	gr := new(GdocRender)
+	for _, p := range findAll(body, atom.P) {
+		if _, err := gr.cleanAtomP(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := renderChildren(t, body)
+	if !strings.HasPrefix(got, `<amp-vimeo data-videoid="76979871"`) {
+		t.Fatalf("expected the lone link embedded, got %s", got)
+	}
+	if !strings.HasSuffix(got, `<p>Watch <a href="https://youtu.be/dQw4w9WgXcQ">this</a></p>`+
+		`<p><a href="https://youtu.be/a">a</a><a href="https://youtu.be/b">b</a></p>`+
+		`<p><a href="https://example.com">example</a></p>`) {
+		t.Fatalf("expected links with text around them left as links, got %s", got)
+	}
+	if strings.Join(gr.AMPComponents, ",") != "amp-vimeo" {
+		t.Fatalf("unexpected components %v", gr.AMPComponents)
+	}
+}
+
+func TestRequireComponent(t *testing.T) {
+	gr := new(GdocRender)
+	for _, name := range []string{"amp-youtube", "amp-iframe", "amp-youtube"} {
+		gr.requireComponent(name)
+	}
+	if strings.Join(gr.AMPComponents, ",") != "amp-iframe,amp-youtube" {
+		t.Fatalf("expected sorted components without duplicates, got %v", gr.AMPComponents)
+	}
+}
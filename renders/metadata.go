@@ -0,0 +1,174 @@
+package renders
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Publishing status of a gdoc
+type GdocStatus string
+
+const (
+	StatusDraft      GdocStatus = "draft"
+	StatusPublished  GdocStatus = "published"
+	StatusDeprecated GdocStatus = "deprecated"
+)
+
+// Expected experience of a lesson's reader
+type GdocLevel string
+
+const (
+	LevelBeginner     GdocLevel = "beginner"
+	LevelIntermediate GdocLevel = "intermediate"
+	LevelAdvanced     GdocLevel = "advanced"
+)
+
+// Where a gdoc is split into steps
+type GdocSteps string
+
+const (
+	StepsNone      GdocSteps = ""
+	StepsPageBreak GdocSteps = "page-break"
+	StepsH1        GdocSteps = "h1"
+)
+
+// Keys understood in a gdoc's metadata table
+var metadataKeys = []string{
+	"TITLE", "SUMMARY", "AUTHOR", "IMAGE", "TOC", "TAGS", "DESCRIPTION",
+	"PUBLISHED", "UPDATED", "CANONICAL", "STATUS", "DURATION", "LEVEL", "STEPS",
+}
+
+// Date formats accepted in the metadata table, tried in order
+var metadataDateFormats = []string{
+	"2006-01-02",
+	time.RFC3339,
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2 January 2006",
+	"1/2/2006",
+}
+
+// matches durations written out such as "1 hour 30 minutes" or "45 min"
+var durationPartPattern = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*(hours|hour|hrs|hr|h|minutes|minute|mins|min|m)\b`)
+
+func parseMetadataDate(value string) (time.Time, error) {
+	for _, format := range metadataDateFormats {
+		if t, err := time.Parse(format, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("Unrecognized date, use YYYY-MM-DD: " + value)
+}
+
+func parseMetadataDuration(value string) (time.Duration, error) {
+	if d, err := time.ParseDuration(strings.Replace(value, " ", "", -1)); err == nil {
+		return d, nil
+	}
+
+	var total time.Duration
+	parts := durationPartPattern.FindAllStringSubmatch(strings.ToLower(value), -1)
+	for _, part := range parts {
+		amount, err := strconv.ParseFloat(part[1], 64)
+		if err != nil {
+			return 0, err
+		}
+		unit := time.Minute
+		if strings.HasPrefix(part[2], "h") {
+			unit = time.Hour
+		}
+		total += time.Duration(amount * float64(unit))
+	}
+	if len(parts) == 0 {
+		return 0, errors.New("Unrecognized duration, use e.g. \"45 min\": " + value)
+	}
+	return total, nil
+}
+
+func parseMetadataStatus(value string) (GdocStatus, error) {
+	switch status := GdocStatus(strings.ToLower(value)); status {
+	case StatusDraft, StatusPublished, StatusDeprecated:
+		return status, nil
+	}
+	return "", errors.New("Status must be draft, published or deprecated: " + value)
+}
+
+func parseMetadataLevel(value string) (GdocLevel, error) {
+	switch level := GdocLevel(strings.ToLower(value)); level {
+	case LevelBeginner, LevelIntermediate, LevelAdvanced:
+		return level, nil
+	}
+	return "", errors.New("Level must be beginner, intermediate or advanced: " + value)
+}
+
+// Accepts "page break", "page-breaks", "h1" or "none"
+func parseMetadataSteps(value string) (GdocSteps, error) {
+	steps := strings.TrimSuffix(strings.Replace(strings.ToLower(value), " ", "-", -1), "s")
+	switch GdocSteps(steps) {
+	case StepsPageBreak, StepsH1:
+		return GdocSteps(steps), nil
+	case "none":
+		return StepsNone, nil
+	}
+	return "", errors.New("Steps must be page-break, h1 or none: " + value)
+}
+
+func parseMetadataCanonical(value string) (string, error) {
+	u, err := url.Parse(value)
+	if err != nil || !u.IsAbs() {
+		return "", errors.New("Canonical must be an absolute url: " + value)
+	}
+	return u.String(), nil
+}
+
+// Splits a comma separated list of tags, dropping empty ones
+func parseMetadataTags(value string) []string {
+	var tags []string
+	for _, tag := range strings.Split(value, ",") {
+		if tag = strings.TrimSpace(tag); len(tag) != 0 {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// Gives the known metadata key closest to a misspelled one, if any is close
+func suggestMetadataKey(key string) (string, bool) {
+	best, bestDistance := "", 3
+	for _, known := range metadataKeys {
+		if d := editDistance(key, known); d < bestDistance {
+			best, bestDistance = known, d
+		}
+	}
+	return best, len(best) != 0
+}
+
+// Levenshtein distance between two strings
+func editDistance(a string, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,81 @@
+package renders
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/cobookman/gcp-quickstart/apiclients"
+)
+
+func parseMetadataFixture(t *testing.T, rows string) (*GdocRender, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(
+		"<html><body><table>" + rows + "</table></body></html>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gr := new(GdocRender)
+	return gr, gr.parseMetadata(doc.Find("body"))
+}
+
+func TestParseMetadata(t *testing.T) {
+	gr, err := parseMetadataFixture(t,
+		`<tr><td>Title</td><td>Deploying to App Engine</td></tr>`+
+			`<tr><td>Tags</td><td>app engine, python , </td></tr>`+
+			`<tr><td>Published</td><td>2017-01-05</td></tr>`+
+			`<tr><td>Updated</td><td>March 2, 2017</td></tr>`+
+			`<tr><td>Status</td><td>Deprecated</td></tr>`+
+			`<tr><td>Duration</td><td>1 hour 30 min</td></tr>`+
+			`<tr><td>Level</td><td>beginner</td></tr>`+
+			`<tr><td>Steps</td><td>Page breaks</td></tr>`+
+			`<tr><td>Canonical</td><td>https://cloud.google.com/appengine/</td></tr>`+
+			`<tr><td>Sumary</td><td>Misspelled</td></tr>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	md := gr.Metadata
+	if md.Title != "Deploying to App Engine" || strings.Join(md.Tags, "|") != "app engine|python" {
+		t.Fatalf("unexpected metadata %+v", md)
+	}
+	if !md.Published.Equal(time.Date(2017, 1, 5, 0, 0, 0, 0, time.UTC)) ||
+		!md.Updated.Equal(time.Date(2017, 3, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected dates %v %v", md.Published, md.Updated)
+	}
+	if md.Status != StatusDeprecated || md.Level != LevelBeginner || md.Duration != 90*time.Minute ||
+		md.Steps != StepsPageBreak {
+		t.Fatalf("unexpected metadata %+v", md)
+	}
+	if len(gr.Warnings) != 1 || !strings.Contains(gr.Warnings[0], "did you mean SUMMARY") {
+		t.Fatalf("expected a warning for the misspelled key, got %v", gr.Warnings)
+	}
+}
+
+func TestParseMetadataInvalidValue(t *testing.T) {
+	if _, err := parseMetadataFixture(t, `<tr><td>Status</td><td>finished</td></tr>`); err == nil {
+		t.Fatal("expected an error for an unknown status")
+	}
+	if _, err := parseMetadataFixture(t, `<tr><td>Published</td><td>yesterday</td></tr>`); err == nil {
+		t.Fatal("expected an error for an unknown date format")
+	}
+}
+
+func TestUseFileTimes(t *testing.T) {
+	created := time.Date(2017, 1, 5, 0, 0, 0, 0, time.UTC)
+	modified := time.Date(2017, 6, 1, 0, 0, 0, 0, time.UTC)
+	times := &apiclients.FileTimes{Created: created, Modified: modified}
+
+	md := new(GdocMetadata)
+	md.useFileTimes(times)
+	if !md.Published.Equal(created) || !md.Updated.Equal(modified) {
+		t.Fatalf("expected drive's times, got %v %v", md.Published, md.Updated)
+	}
+
+	published := time.Date(2017, 9, 1, 0, 0, 0, 0, time.UTC)
+	md = &GdocMetadata{Published: published}
+	md.useFileTimes(times)
+	if !md.Published.Equal(published) || !md.Updated.Equal(published) {
+		t.Fatalf("expected the metadata's date to win, got %v %v", md.Published, md.Updated)
+	}
+}
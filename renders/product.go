@@ -0,0 +1,38 @@
+package renders
+
+import (
+  "bytes"
+  "github.com/cobookman/gcp-quickstart/templates"
+  "github.com/cobookman/gcp-quickstart/layout"
+)
+
+// Renders a product's landing page, listing its lessons. Lessons must be
+// built first, for their details to be known.
+func RenderProduct(layout *layout.Layout, product *layout.Product, buildFolder string, domain string) error {
+  buf := new(bytes.Buffer)
+  if err := templates.Templates().ExecuteTemplate(buf, "product", product); err != nil {
+    return err
+  }
+
+  pg := &templates.PageMetadata{
+    Title: "GCP Quickstarts - " + product.Name,
+    Description: product.Summary,
+    Image: product.Icon,
+    Type: templates.TypeWebsite,
+    FilePath: product.Href(),
+    Domain: domain,
+    Updated: product.LastUpdated(layout),
+    ArticleHTML: templates.RenderHTML(buf.String()),
+    Layout: layout,
+  }
+  collection := templates.NewCollectionPage(pg)
+  if !pg.Updated.IsZero() {
+    collection.DateModified = &pg.Updated
+  }
+  pg.StructuredData = []templates.StructuredData{
+    collection,
+    templates.ProductBreadcrumbs(domain, product),
+  }
+
+  return templates.RenderPage(pg, buildFolder)
+}
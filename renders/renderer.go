@@ -0,0 +1,175 @@
+package renders
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cobookman/gcp-quickstart/layout"
+)
+
+// What a renderer builds a lesson with
+type BuildContext struct {
+	Layout           *layout.Layout
+	BuildFolder      string
+	Domain           string
+	ClientSecretPath string
+	// folder of the markdown lessons
+	ContentDir string
+	ClaatPath  string
+	GA         string
+}
+
+// What a renderer built, along with the problems it found in the source
+type Output struct {
+	Source string
+	Title  string
+	// Site paths of the built pages, the first of which is the lesson's own.
+	// Lessons hosted elsewhere have none. Drafts give the paths their pages
+	// would have.
+	Pages []string
+	// Whether the pages are AMP, and so should pass AMP validation. Claat's
+	// pages are its own html.
+	AMP bool
+	// when the source was last updated, if it is known
+	Updated time.Time
+	// Drafts are rendered for their warnings, but not written out
+	Draft    bool
+	Warnings []string
+	Comments []*GdocComment
+	// Writes the pages out, for renderers which leave that until every lesson
+	// is rendered, so that no page links to a draft. It may add warnings. Nil
+	// for renderers which write their pages as they render.
+	Write func() error
+}
+
+// Builds lessons of one source type into the build folder. Renderers fill in
+// the lesson with the details only its source knows, such as its duration and
+// whether it is a draft.
+type Renderer interface {
+	// The lesson's source of this renderer's type, or "" if it has none.
+	// Renderers registered by other packages find theirs in lesson.Sources.
+	Source(lesson *layout.Lesson) string
+	Render(lesson *layout.Lesson, ctx *BuildContext) (*Output, error)
+}
+
+// Version given by checkers for builds of a tool which are not released, and
+// so cannot be checked to be recent enough
+const DevVersion = "dev"
+
+// Renderers which need a tool installed before the build starts, such as
+// claat, check for it
+type Checker interface {
+	// Gives the version of the tool found, or DevVersion
+	Check(ctx *BuildContext) (string, error)
+}
+
+type registeredRenderer struct {
+	name     string
+	renderer Renderer
+}
+
+// Renderers in the order they are tried, so that a lesson with several
+// sources is built from the first one registered
+var renderers = []registeredRenderer{
+	{"claat", claatRenderer{}},
+	{"gdoc", gdocRenderer{}},
+	{"markdown", markdownRenderer{}},
+	{"url", urlRenderer{}},
+}
+
+// Adds a renderer for a new source type. Packages registering their own
+// renderers do so from their init functions.
+func Register(name string, renderer Renderer) {
+	for _, r := range renderers {
+		if r.name == name {
+			panic(fmt.Sprintf("renders: renderer %s is already registered", name))
+		}
+	}
+	renderers = append(renderers, registeredRenderer{name, renderer})
+}
+
+// Gives the name of the renderer which builds the lesson and the renderer,
+// or a nil renderer if the lesson has no source any renderer knows.
+func RendererFor(lesson *layout.Lesson) (string, Renderer) {
+	for _, r := range renderers {
+		if len(r.renderer.Source(lesson)) != 0 {
+			return r.name, r.renderer
+		}
+	}
+	return "", nil
+}
+
+type gdocRenderer struct{}
+
+func (gdocRenderer) Source(lesson *layout.Lesson) string {
+	return lesson.SourceGDoc
+}
+
+func (gdocRenderer) Render(lesson *layout.Lesson, ctx *BuildContext) (*Output, error) {
+	gr, err := RenderGdoc(ctx.Layout, ctx.ClientSecretPath, lesson.SourceGDoc, ctx.BuildFolder, lesson.Href, ctx.Domain)
+	if err != nil {
+		return nil, err
+	}
+	return writtenLater(gr), nil
+}
+
+// Gives the output of a gdoc or markdown render, whose pages are written
+// once every lesson is rendered
+func writtenLater(gr *GdocRender) *Output {
+	out := gr.Output()
+	out.Write = func() error {
+		err := gr.Write()
+		out.Warnings = gr.Warnings
+		return err
+	}
+	return out
+}
+
+type markdownRenderer struct{}
+
+func (markdownRenderer) Source(lesson *layout.Lesson) string {
+	return lesson.SourceMarkdown
+}
+
+func (markdownRenderer) Render(lesson *layout.Lesson, ctx *BuildContext) (*Output, error) {
+	gr, err := RenderMarkdown(ctx.Layout, ctx.ContentDir, lesson.SourceMarkdown, ctx.BuildFolder, lesson.Href, ctx.Domain)
+	if err != nil {
+		return nil, err
+	}
+	return writtenLater(gr), nil
+}
+
+type claatRenderer struct{}
+
+func (claatRenderer) Source(lesson *layout.Lesson) string {
+	return lesson.SourceClaat
+}
+
+func (claatRenderer) Check(ctx *BuildContext) (string, error) {
+	return CheckClaat(ctx.ClaatPath)
+}
+
+func (claatRenderer) Render(lesson *layout.Lesson, ctx *BuildContext) (*Output, error) {
+	cr, err := RenderClaat(lesson, ctx.ClaatPath, ctx.GA, ctx.BuildFolder, ctx.Domain)
+	if err != nil {
+		return nil, err
+	}
+	return &Output{
+		Source:   lesson.SourceClaat,
+		Title:    cr.Codelab.Title,
+		Pages:    []string{lesson.Href},
+		Updated:  lesson.Updated,
+		Warnings: cr.Warnings,
+	}, nil
+}
+
+// Lessons hosted elsewhere, which are only linked to
+type urlRenderer struct{}
+
+func (urlRenderer) Source(lesson *layout.Lesson) string {
+	return lesson.SourceURL
+}
+
+func (urlRenderer) Render(lesson *layout.Lesson, ctx *BuildContext) (*Output, error) {
+	return &Output{Source: lesson.SourceURL, Title: lesson.Name}, nil
+}
@@ -0,0 +1,56 @@
+package renders
+
+import (
+	"testing"
+
+	"github.com/cobookman/gcp-quickstart/layout"
+)
+
+type fakeRenderer struct{}
+
+func (fakeRenderer) Source(lesson *layout.Lesson) string {
+	return lesson.Sources["fake"]
+}
+
+func (fakeRenderer) Render(lesson *layout.Lesson, ctx *BuildContext) (*Output, error) {
+	return &Output{Source: lesson.Sources["fake"], Pages: []string{lesson.Href}}, nil
+}
+
+func TestRendererFor(t *testing.T) {
+	lesson := &layout.Lesson{SourceURL: "https://example.com", SourceGDoc: "https://docs.google.com/document/d/abc/edit"}
+	if name, _ := RendererFor(lesson); name != "gdoc" {
+		t.Fatalf("expected the gdoc renderer to come before the url one, got %s", name)
+	}
+	other := &layout.Other{URL: "/about/index.html", SourceGDoc: "https://docs.google.com/document/d/def/edit"}
+	if name, _ := RendererFor(other.Lesson()); name != "gdoc" || other.Lesson().Href != other.URL {
+		t.Fatalf("expected other pages to be built by the gdoc renderer at their url, got %s", name)
+	}
+	if _, renderer := RendererFor(&layout.Lesson{}); renderer != nil {
+		t.Fatal("expected no renderer for a lesson without a source")
+	}
+	if _, ok := renderers[0].renderer.(Checker); !ok {
+		t.Fatal("expected the claat renderer to check for claat")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	defer func(registered []registeredRenderer) { renderers = registered }(renderers)
+
+	Register("fake", fakeRenderer{})
+	name, renderer := RendererFor(&layout.Lesson{Sources: map[string]string{"fake": "fake source"}, Href: "/a/b/index.html"})
+	if name != "fake" {
+		t.Fatalf("expected the registered renderer, got %q", name)
+	}
+	out, err := renderer.Render(&layout.Lesson{Sources: map[string]string{"fake": "fake source"}, Href: "/a/b/index.html"}, &BuildContext{})
+	if err != nil || out.Pages[0] != "/a/b/index.html" {
+		t.Fatalf("unexpected output %+v %v", out, err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected registering a name twice to panic")
+		}
+	}()
+	Register("gdoc", fakeRenderer{})
+}
+
@@ -0,0 +1,118 @@
+package renders
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Attributes allowed on every element
+var sanitizedGlobalAttrs = []string{"id", "class"}
+
+// Elements allowed in rendered articles, along with the attributes each
+// allows on top of the global ones.
+var sanitizedElements = map[string][]string{
+	"p": nil, "br": nil, "hr": nil, "div": nil, "span": nil,
+	"h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+	"a": {"href"},
+	"b": nil, "strong": nil, "i": nil, "em": nil, "u": nil, "s": nil,
+	"sup": nil, "sub": nil, "small": nil, "mark": nil, "del": nil, "ins": nil,
+	"code": nil, "pre": nil, "kbd": nil, "blockquote": nil,
+	"ul": nil, "ol": {"start", "type"}, "li": {"value"},
+	"dl": nil, "dt": nil, "dd": nil,
+	"table": nil, "caption": nil, "thead": nil, "tbody": nil, "tfoot": nil, "tr": nil,
+	"th": {"colspan", "rowspan"}, "td": {"colspan", "rowspan"},
+	"section": nil, "aside": nil, "nav": nil, "figure": nil, "figcaption": nil,
+	"amp-img":     {"src", "alt", "width", "height", "layout"},
+	"amp-youtube": {"data-videoid", "width", "height", "layout"},
+	"amp-vimeo":   {"data-videoid", "width", "height", "layout"},
+	"amp-iframe":  {"src", "sandbox", "frameborder", "allowfullscreen", "width", "height", "layout"},
+}
+
+// Elements removed along with their contents, as their contents are not
+// meant to be read.
+var sanitizedDroppedElements = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true,
+	"iframe": true, "object": true, "embed": true, "applet": true,
+	"form": true, "input": true, "button": true, "select": true, "textarea": true,
+	"svg": true, "math": true, "head": true, "title": true,
+	"meta": true, "link": true, "base": true,
+}
+
+// Url schemes allowed in href and src attributes. Relative urls are allowed.
+var sanitizedSchemes = map[string]bool{"http": true, "https": true, "mailto": true}
+
+// Strips the children of n down to allowlisted elements, attributes and url
+// schemes, so that a shared gdoc cannot inject scripts into the site. Unknown
+// elements are replaced by their contents. Each removal is reported to warn.
+func sanitizeChildren(n *html.Node, warn func(string)) {
+	var next *html.Node
+	for c := n.FirstChild; c != nil; c = next {
+		next = c.NextSibling
+
+		switch c.Type {
+		case html.TextNode:
+			continue
+		case html.ElementNode:
+		default:
+			// comments and doctypes
+			n.RemoveChild(c)
+			continue
+		}
+
+		if sanitizedDroppedElements[c.Data] {
+			warn("removed <" + c.Data + "> and its contents")
+			n.RemoveChild(c)
+			continue
+		}
+
+		sanitizeChildren(c, warn)
+		allowed, ok := sanitizedElements[c.Data]
+		if !ok {
+			warn("removed <" + c.Data + ">, keeping its contents")
+			for gc := c.FirstChild; gc != nil; gc = c.FirstChild {
+				c.RemoveChild(gc)
+				n.InsertBefore(gc, c)
+			}
+			n.RemoveChild(c)
+			continue
+		}
+		sanitizeAttributes(c, allowed, warn)
+	}
+}
+
+func sanitizeAttributes(n *html.Node, allowed []string, warn func(string)) {
+	var attrs []html.Attribute
+	for _, attr := range n.Attr {
+		switch {
+		case !containsString(sanitizedGlobalAttrs, attr.Key) && !containsString(allowed, attr.Key):
+			warn("removed attribute " + attr.Key + " from <" + n.Data + ">")
+		case (attr.Key == "href" || attr.Key == "src") && !safeURL(attr.Val):
+			warn("removed unsafe url " + attr.Val + " from <" + n.Data + ">")
+		case n.Data == "amp-iframe" && attr.Key == "src" && !strings.HasPrefix(attr.Val, "https://"):
+			warn("removed non https url " + attr.Val + " from <amp-iframe>")
+		default:
+			attrs = append(attrs, attr)
+		}
+	}
+	n.Attr = attrs
+}
+
+// Whether a url is relative or has an allowlisted scheme
+func safeURL(value string) bool {
+	u, err := url.Parse(strings.TrimSpace(value))
+	if err != nil {
+		return false
+	}
+	return len(u.Scheme) == 0 || sanitizedSchemes[strings.ToLower(u.Scheme)]
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package renders
+
+import (
+	"net/url"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestSanitizeRemovesUnsafeHTML(t *testing.T) {
+	body := parseBody(t,
+		`<p><a href="javascript:alert(1)">a</a> <a href="JavaScript:alert(1)">b</a> <a href="mailto:x@example.com">c</a></p>`+
+			`<p onclick="alert(1)">d<script>alert(1)</script><!-- note --></p>`+
+			`<iframe src="https://example.com"></iframe>`+
+			`<p><font color="red">e</font> <a href="/relative#x">f</a></p>`+
+			`<p><a href="data:text/html;base64,PHNjcmlwdD4=">g</a></p>`)
+	var warnings []string
+	sanitizeChildren(body, func(msg string) { warnings = append(warnings, msg) })
+
+	want := `<p><a>a</a> <a>b</a> <a href="mailto:x@example.com">c</a></p>` +
+		`<p>d</p>` +
+		`<p>e <a href="/relative#x">f</a></p>` +
+		`<p><a>g</a></p>`
+	if got := renderChildren(t, body); got != want {
+		t.Fatalf("got %s\nwant %s", got, want)
+	}
+	if len(warnings) != 7 {
+		t.Fatalf("expected a warning per removal, got %v", warnings)
+	}
+}
+
+func TestSanitizeKeepsRenderedHTML(t *testing.T) {
+	u, _ := url.Parse("https://docs.google.com/presentation/d/1slides/edit")
+	body := parseBody(t,
+		`<pre><code class="language-go"><span class="k">func</span></code></pre>`+
+			`<ol start="3"><li value="4">one</li></ol>`+
+			`<aside class="callout callout-note"><div class="callout-title">Note</div></aside>`+
+			`<div class="table-wrapper"><table><tr><td colspan="2">a</td></tr></table></div>`)
+	body.AppendChild(mediaEmbed(u))
+	want := renderChildren(t, body)
+
+	var warnings []string
+	sanitizeChildren(body, func(msg string) { warnings = append(warnings, msg) })
+	if got := renderChildren(t, body); got != want {
+		t.Fatalf("got %s\nwant %s", got, want)
+	}
+	if len(warnings) != 0 {
+		t.Fatalf("unexpected warnings %v", warnings)
+	}
+}
+
+func TestSanitizeNonHTTPSIframe(t *testing.T) {
+	body := parseBody(t, "")
+	iframe := &html.Node{Type: html.ElementNode, Data: "amp-iframe", Attr: []html.Attribute{{Key: "src", Val: "http://example.com"}}}
+	body.AppendChild(iframe)
+
+	var warnings []string
+	sanitizeChildren(body, func(msg string) { warnings = append(warnings, msg) })
+	if len(iframe.Attr) != 0 || len(warnings) != 1 {
+		t.Fatalf("expected the http src removed, got %v %v", iframe.Attr, warnings)
+	}
+}
+
+func TestSafeURL(t *testing.T) {
+	for value, want := range map[string]bool{
+		"https://example.com":  true,
+		"/compute/index.html":  true,
+		"#fn-1":                true,
+		"MAILTO:x@example.com": true,
+		" javascript:alert(1)": false,
+		"data:text/html,hi":    false,
+		"vbscript:msgbox(1)":   false,
+	} {
+		if got := safeURL(value); got != want {
+			t.Fatalf("safeURL(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
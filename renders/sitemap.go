@@ -0,0 +1,123 @@
+package renders
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/cobookman/gcp-quickstart/templates"
+)
+
+const (
+	sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+	sitemapFile      = "sitemap.xml"
+)
+
+// Most urls a single sitemap may list. Larger sites are split into several
+// sitemaps, listed by a sitemap index.
+var sitemapURLLimit = 50000
+
+// A built page, and when it was last updated if that is known
+type SitemapPage struct {
+	Path    string
+	Updated time.Time
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name      `xml:"urlset"`
+	XMLNS   string        `xml:"xmlns,attr"`
+	URLs    []*sitemapURL `xml:"url"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name      `xml:"sitemapindex"`
+	XMLNS    string        `xml:"xmlns,attr"`
+	Sitemaps []*sitemapURL `xml:"sitemap"`
+}
+
+// Writes sitemap.xml to the build folder, listing the pages by their absolute
+// urls. Past the url limit sitemap.xml is an index of sitemap-1.xml,
+// sitemap-2.xml and so on.
+func RenderSitemap(pages []*SitemapPage, buildFolder string, domain string) error {
+	if len(pages) <= sitemapURLLimit {
+		return writeSitemap(filepath.Join(buildFolder, sitemapFile), sitemapURLs(pages, domain))
+	}
+
+	index := &sitemapIndex{XMLNS: sitemapNamespace}
+	for i := 0; i*sitemapURLLimit < len(pages); i++ {
+		chunk := pages[i*sitemapURLLimit:]
+		if len(chunk) > sitemapURLLimit {
+			chunk = chunk[:sitemapURLLimit]
+		}
+		name := "sitemap-" + strconv.Itoa(i+1) + ".xml"
+		if err := writeSitemap(filepath.Join(buildFolder, name), sitemapURLs(chunk, domain)); err != nil {
+			return err
+		}
+
+		var updated time.Time
+		for _, page := range chunk {
+			if page.Updated.After(updated) {
+				updated = page.Updated
+			}
+		}
+		index.Sitemaps = append(index.Sitemaps, &sitemapURL{
+			Loc:     templates.AbsoluteURL(domain, "/"+name),
+			LastMod: sitemapTime(updated),
+		})
+	}
+	return writeXML(filepath.Join(buildFolder, sitemapFile), index)
+}
+
+// Writes robots.txt to the build folder, allowing every page and pointing
+// crawlers at the sitemap
+func RenderRobots(buildFolder string, domain string) error {
+	robots := "User-agent: *\nAllow: /\n\nSitemap: " + templates.AbsoluteURL(domain, "/"+sitemapFile) + "\n"
+	return ioutil.WriteFile(filepath.Join(buildFolder, "robots.txt"), []byte(robots), 0644)
+}
+
+func sitemapURLs(pages []*SitemapPage, domain string) []*sitemapURL {
+	var urls []*sitemapURL
+	for _, page := range pages {
+		urls = append(urls, &sitemapURL{
+			Loc:     templates.AbsoluteURL(domain, page.Path),
+			LastMod: sitemapTime(page.Updated),
+		})
+	}
+	return urls
+}
+
+func writeSitemap(path string, urls []*sitemapURL) error {
+	return writeXML(path, &sitemapURLSet{XMLNS: sitemapNamespace, URLs: urls})
+}
+
+func writeXML(path string, v interface{}) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(f)
+	enc.Indent("", "  ")
+	return enc.Encode(v)
+}
+
+// Formats a lastmod in the w3c datetime format, or gives "" if the time is
+// not known
+func sitemapTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
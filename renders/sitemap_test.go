@@ -0,0 +1,81 @@
+package renders
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readBuilt(t *testing.T, dir string, name string) string {
+	b, err := ioutil.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestRenderSitemap(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sitemap-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	updated := time.Date(2017, 3, 4, 5, 6, 7, 0, time.UTC)
+	pages := []*SitemapPage{
+		{Path: "/index.html"},
+		{Path: "/compute/gce/intro/index.html", Updated: updated},
+	}
+	if err := RenderSitemap(pages, dir, "https://example.com/"); err != nil {
+		t.Fatal(err)
+	}
+	sitemap := readBuilt(t, dir, "sitemap.xml")
+	for _, want := range []string{
+		`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`,
+		"<url>\n    <loc>https://example.com/index.html</loc>\n  </url>",
+		"<loc>https://example.com/compute/gce/intro/index.html</loc>\n    <lastmod>2017-03-04T05:06:07Z</lastmod>",
+	} {
+		if !strings.Contains(sitemap, want) {
+			t.Fatalf("missing %s in %s", want, sitemap)
+		}
+	}
+
+	if err := RenderRobots(dir, "https://example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if robots := readBuilt(t, dir, "robots.txt"); !strings.Contains(robots, "Sitemap: https://example.com/sitemap.xml\n") {
+		t.Fatalf("expected robots.txt to point at the sitemap, got %s", robots)
+	}
+}
+
+func TestRenderSitemapIndex(t *testing.T) {
+	defer func(limit int) { sitemapURLLimit = limit }(sitemapURLLimit)
+	sitemapURLLimit = 2
+
+	dir, err := ioutil.TempDir("", "sitemap-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	updated := time.Date(2017, 3, 4, 0, 0, 0, 0, time.UTC)
+	pages := []*SitemapPage{{Path: "/a.html"}, {Path: "/b.html"}, {Path: "/c.html", Updated: updated}}
+	if err := RenderSitemap(pages, dir, "https://example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	index := readBuilt(t, dir, "sitemap.xml")
+	if !strings.Contains(index, "<sitemapindex") || strings.Count(index, "<sitemap>") != 2 ||
+		!strings.Contains(index, "<loc>https://example.com/sitemap-2.xml</loc>\n    <lastmod>2017-03-04T00:00:00Z</lastmod>") {
+		t.Fatalf("unexpected sitemap index %s", index)
+	}
+	if first := readBuilt(t, dir, "sitemap-1.xml"); strings.Count(first, "<url>") != 2 {
+		t.Fatalf("expected the first sitemap to be full, got %s", first)
+	}
+	if second := readBuilt(t, dir, "sitemap-2.xml"); !strings.Contains(second, "https://example.com/c.html") {
+		t.Fatalf("expected the last page in the second sitemap, got %s", second)
+	}
+}
@@ -0,0 +1,183 @@
+package renders
+
+import (
+	"bytes"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cobookman/gcp-quickstart/templates"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	pageBreakClass = "page-break"
+	// words read per minute, to estimate the time a step takes
+	readingSpeed = 200
+)
+
+// A page of a gdoc split into steps
+type GdocStep struct {
+	Number   int
+	Title    string
+	Path     string
+	HTML     string
+	Duration time.Duration
+	// entries of the article's table of contents which are in the step
+	TOC []*templates.TOCEntry
+	// AMP components used by the step, out of those the article uses
+	AMPComponents []string
+}
+
+// Marks the page breaks gdoc exports as hidden <hr>s, so that they are still
+// known once their style is cleaned.
+func (gr *GdocRender) markPageBreaks(body *html.Node) {
+	for n := body.FirstChild; n != nil; n = n.NextSibling {
+		if n.DataAtom == atom.Hr && gr.nodeStyle(n, "page-break-before") == "always" {
+			gr.keepAttr(n, "class", pageBreakClass)
+		}
+	}
+}
+
+// Splits the rendered article into steps at its page breaks or h1s, as set by
+// the gdoc's metadata. Content before the first h1 is kept in the first step.
+// Links to an anchor in another step are pointed at that step's page.
+func (gr *GdocRender) splitSteps(body *html.Node) {
+	gr.Steps = nil
+	var groups [][]*html.Node
+	var group []*html.Node
+	hasHeading := false
+	for n := body.FirstChild; n != nil; n = n.NextSibling {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		switch {
+		case gr.Metadata.Steps == StepsPageBreak && n.DataAtom == atom.Hr && hasClass(n, pageBreakClass):
+			if len(group) != 0 {
+				groups, group = append(groups, group), nil
+			}
+			continue
+		case gr.Metadata.Steps == StepsH1 && n.DataAtom == atom.H1:
+			if hasHeading {
+				groups, group = append(groups, group), nil
+			}
+			hasHeading = true
+		}
+		group = append(group, n)
+	}
+	if len(group) != 0 {
+		groups = append(groups, group)
+	}
+	if len(groups) < 2 {
+		gr.warn("gdoc is split into steps at " + string(gr.Metadata.Steps) + " but has only one step")
+		return
+	}
+
+	// the step each element id is in
+	owners := make(map[string]int)
+	words := make([]int, len(groups))
+	totalWords := 0
+	for i, group := range groups {
+		number := i + 1
+		step := &GdocStep{
+			Number: number,
+			Title:  stepTitle(group, number),
+			Path:   stepPath(gr.Path, number),
+		}
+		gr.Steps = append(gr.Steps, step)
+		for _, n := range group {
+			forEachElement(n, func(e *html.Node) {
+				if id := nodeAttr(e, "id"); len(id) != 0 {
+					owners[id] = i
+				}
+				if containsString(gr.AMPComponents, e.Data) && !containsString(step.AMPComponents, e.Data) {
+					step.AMPComponents = append(step.AMPComponents, e.Data)
+				}
+			})
+			walkText(n, func(t *html.Node) {
+				words[i] += len(strings.Fields(t.Data))
+			})
+		}
+		totalWords += words[i]
+	}
+	for _, entry := range gr.TOC {
+		if owner, ok := owners[entry.ID]; ok {
+			gr.Steps[owner].TOC = append(gr.Steps[owner].TOC, entry)
+		}
+	}
+
+	for i, group := range groups {
+		step := gr.Steps[i]
+		buf := new(bytes.Buffer)
+		for _, n := range group {
+			forEachElement(n, func(a *html.Node) {
+				href := nodeAttr(a, "href")
+				if a.DataAtom != atom.A || !strings.HasPrefix(href, "#") {
+					return
+				}
+				if owner, ok := owners[strings.TrimPrefix(href, "#")]; ok && owner != i {
+					setNodeAttr(a, "href", gr.Steps[owner].Path+href)
+				}
+			})
+			html.Render(buf, n)
+		}
+		step.HTML = buf.String()
+		step.Duration = stepDuration(gr.Metadata.Duration, words[i], totalWords)
+		sort.Strings(step.AMPComponents)
+	}
+}
+
+// Splits the lesson's duration between steps by their length, or estimates
+// it from the reading speed if the lesson has no duration.
+func stepDuration(total time.Duration, words int, totalWords int) time.Duration {
+	if total > 0 && totalWords > 0 {
+		return time.Duration(float64(total) * float64(words) / float64(totalWords))
+	}
+	d := time.Duration(words) * time.Minute / readingSpeed
+	if d < time.Minute {
+		d = time.Minute
+	}
+	return d
+}
+
+// The first step is at the page's own path, the following ones next to it,
+// such as /compute/gce/intro/step-2.html
+func stepPath(pagePath string, number int) string {
+	if number == 1 {
+		return pagePath
+	}
+	dir, file := path.Split(pagePath)
+	if file == "index.html" {
+		return dir + "step-" + strconv.Itoa(number) + ".html"
+	}
+	return strings.TrimSuffix(pagePath, path.Ext(pagePath)) + "-step-" + strconv.Itoa(number) + ".html"
+}
+
+// Titles a step after its first h1 or h2
+func stepTitle(group []*html.Node, number int) string {
+	title := ""
+	for _, n := range group {
+		forEachElement(n, func(e *html.Node) {
+			if len(title) == 0 && (e.DataAtom == atom.H1 || e.DataAtom == atom.H2) {
+				title = strings.Join(strings.Fields(codeText(e)), " ")
+			}
+		})
+	}
+	if len(title) == 0 {
+		return "Step " + strconv.Itoa(number)
+	}
+	return title
+}
+
+// Calls fn on n and every element under it
+func forEachElement(n *html.Node, fn func(*html.Node)) {
+	if n.Type == html.ElementNode {
+		fn(n)
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		forEachElement(c, fn)
+	}
+}
@@ -0,0 +1,108 @@
+package renders
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cobookman/gcp-quickstart/templates"
+	"golang.org/x/net/html/atom"
+)
+
+func TestSplitStepsAtH1(t *testing.T) {
+	gr := &GdocRender{
+		Path:     "/compute/gce/intro/index.html",
+		Metadata: &GdocMetadata{Steps: StepsH1, Duration: 30 * time.Minute},
+		TOC:      []*templates.TOCEntry{{ID: "overview"}, {ID: "install"}},
+	}
+	gr.splitSteps(parseBody(t,
+		`<p>Intro <a href="#set-up">see setup</a></p>`+
+			`<h1 id="overview">Overview</h1><p>one two three four five six</p>`+
+			`<h1 id="set-up">Set up</h1><h2 id="install">Install</h2><p>one two three four five six seven eight nine</p>`))
+
+	if len(gr.Steps) != 2 {
+		t.Fatalf("expected 2 steps, got %d", len(gr.Steps))
+	}
+	first, second := gr.Steps[0], gr.Steps[1]
+	if first.Title != "Overview" || first.Path != "/compute/gce/intro/index.html" {
+		t.Fatalf("unexpected first step %+v", first)
+	}
+	if second.Title != "Set up" || second.Path != "/compute/gce/intro/step-2.html" {
+		t.Fatalf("unexpected second step %+v", second)
+	}
+	if !strings.HasPrefix(first.HTML, `<p>Intro <a href="/compute/gce/intro/step-2.html#set-up">see setup</a></p><h1 id="overview">`) {
+		t.Fatalf("expected the intro in the first step, linking to the second, got %s", first.HTML)
+	}
+	if len(first.TOC) != 1 || first.TOC[0].ID != "overview" || len(second.TOC) != 1 || second.TOC[0].ID != "install" {
+		t.Fatalf("expected the toc split between the steps, got %v %v", first.TOC, second.TOC)
+	}
+	if total := first.Duration + second.Duration; total < 30*time.Minute-time.Second || total > 30*time.Minute ||
+		second.Duration <= first.Duration {
+		t.Fatalf("expected the duration split by length, got %v %v", first.Duration, second.Duration)
+	}
+}
+
+func TestSplitStepsAtPageBreaks(t *testing.T) {
+	gr := &GdocRender{
+		Path:          "/about.html",
+		Metadata:      &GdocMetadata{Steps: StepsPageBreak},
+		AMPComponents: []string{"amp-youtube"},
+	}
+	gr.splitSteps(parseBody(t,
+		`<p>first</p><hr class="page-break"/><hr/>`+
+			`<p>second</p><amp-youtube data-videoid="abc"></amp-youtube><hr class="page-break"/>`))
+
+	if len(gr.Steps) != 2 {
+		t.Fatalf("expected 2 steps, got %d", len(gr.Steps))
+	}
+	if gr.Steps[0].HTML != "<p>first</p>" || gr.Steps[1].HTML != `<hr/><p>second</p><amp-youtube data-videoid="abc"></amp-youtube>` {
+		t.Fatalf("unexpected steps %q %q", gr.Steps[0].HTML, gr.Steps[1].HTML)
+	}
+	if len(gr.Steps[0].AMPComponents) != 0 || strings.Join(gr.Steps[1].AMPComponents, ",") != "amp-youtube" {
+		t.Fatalf("expected only the second step to use amp-youtube, got %v %v", gr.Steps[0].AMPComponents, gr.Steps[1].AMPComponents)
+	}
+	if gr.Steps[1].Title != "Step 2" || gr.Steps[1].Path != "/about-step-2.html" {
+		t.Fatalf("unexpected second step %+v", gr.Steps[1])
+	}
+	if gr.Steps[0].Duration != time.Minute {
+		t.Fatalf("expected a minimum of a minute, got %v", gr.Steps[0].Duration)
+	}
+}
+
+func TestSplitStepsWarnsOnSingleStep(t *testing.T) {
+	gr := &GdocRender{Metadata: &GdocMetadata{Steps: StepsH1}}
+	gr.splitSteps(parseBody(t, `<h1>Only</h1><p>text</p>`))
+	if len(gr.Steps) != 0 || len(gr.Warnings) != 1 {
+		t.Fatalf("expected no steps and a warning, got %v %v", gr.Steps, gr.Warnings)
+	}
+}
+
+func TestMarkPageBreaks(t *testing.T) {
+	gr := new(GdocRender)
+	body := parseBody(t, `<hr style="page-break-before:always;display:none;"><hr><div><hr style="page-break-before:always"></div>`)
+	gr.markPageBreaks(body)
+
+	hrs := findAll(body, atom.Hr)
+	if len(gr.attrs[hrs[0]]) != 1 || gr.attrs[hrs[0]][0].Val != pageBreakClass {
+		t.Fatalf("expected the page break marked, got %v", gr.attrs[hrs[0]])
+	}
+	if len(gr.attrs) != 1 {
+		t.Fatalf("expected only top level page breaks marked, got %d", len(gr.attrs))
+	}
+}
+
+func TestStepPath(t *testing.T) {
+	for _, c := range []struct {
+		page   string
+		number int
+		want   string
+	}{
+		{"/compute/gce/intro/index.html", 1, "/compute/gce/intro/index.html"},
+		{"/compute/gce/intro/index.html", 3, "/compute/gce/intro/step-3.html"},
+		{"/about.html", 2, "/about-step-2.html"},
+	} {
+		if got := stepPath(c.page, c.number); got != c.want {
+			t.Fatalf("stepPath(%s, %d) = %s, want %s", c.page, c.number, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package renders
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	tableWrapperClass = "table-wrapper"
+)
+
+// Gdoc tables have no header cells. Turns the first row of a table into a
+// <thead> of <th> cells when it is styled as a header: bold text or a
+// background color which the rest of the table does not have.
+func (gr *GdocRender) renderTables(body *html.Node) {
+	for n := body.FirstChild; n != nil; n = n.NextSibling {
+		if n.DataAtom != atom.Table {
+			continue
+		}
+		rows := findAll(n, atom.Tr)
+		if len(rows) < 2 || !gr.isHeaderRow(rows[0], rows[1]) {
+			continue
+		}
+
+		thead := &html.Node{Type: html.ElementNode, DataAtom: atom.Thead, Data: "thead"}
+		header := rows[0]
+		section := header
+		if header.Parent.DataAtom == atom.Tbody {
+			section = header.Parent
+		}
+		section.Parent.InsertBefore(thead, section)
+		header.Parent.RemoveChild(header)
+		thead.AppendChild(header)
+		for _, td := range findAll(header, atom.Td) {
+			td.DataAtom = atom.Th
+			td.Data = "th"
+		}
+	}
+}
+
+// True if the row is styled differently than the row after it, either by all
+// of its text being bold or by all of its cells having their own background.
+func (gr GdocRender) isHeaderRow(row *html.Node, nextRow *html.Node) bool {
+	hasText := false
+	isBold := true
+	walkText(row, func(t *html.Node) {
+		if len(strings.TrimSpace(t.Data)) == 0 {
+			return
+		}
+		hasText = true
+		weight := gr.inheritedStyle(t.Parent, "font-weight")
+		if weight != "bold" && weight != "700" && weight != "800" && weight != "900" {
+			isBold = false
+		}
+	})
+	if hasText && isBold {
+		return true
+	}
+
+	background := ""
+	for _, td := range findAll(row, atom.Td) {
+		color := gr.nodeStyle(td, "background-color")
+		if len(color) == 0 || len(background) != 0 && color != background {
+			return false
+		}
+		background = color
+	}
+	for _, td := range findAll(nextRow, atom.Td) {
+		if gr.nodeStyle(td, "background-color") == background {
+			return false
+		}
+	}
+	return len(background) != 0
+}
+
+// Cleans up a <table> node, wrapping it so that wide tables scroll on their
+// own instead of widening the page.
+func (gr *GdocRender) cleanAtomTable(n *html.Node) (*html.Node, error) {
+	wrapper := &html.Node{
+		Type:     html.ElementNode,
+		DataAtom: atom.Div,
+		Data:     "div",
+		Attr:     []html.Attribute{{Key: "class", Val: tableWrapperClass}},
+	}
+	if n.Parent != nil {
+		n.Parent.InsertBefore(wrapper, n)
+		n.Parent.RemoveChild(n)
+	}
+	wrapper.AppendChild(n)
+	return wrapper, nil
+}
+
+// Cleans up a <td> or <th> node. Gdoc wraps the content of every cell in a
+// paragraph, which is removed when it is the only thing in the cell, along
+// with any spans left without styling.
+func (gr *GdocRender) cleanAtomCell(n *html.Node) (*html.Node, error) {
+	if n.FirstChild != nil && n.FirstChild == n.LastChild && n.FirstChild.DataAtom == atom.P {
+		unwrapNode(n.FirstChild)
+	}
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.DataAtom == atom.Span && len(c.Attr) == 0 {
+			unwrapNode(c)
+		}
+		c = next
+	}
+
+	// gdoc sets colspan and rowspan on every cell, even when they span nothing
+	for _, attr := range []string{"colspan", "rowspan"} {
+		if nodeAttr(n, attr) == "1" {
+			delNodeAttr(n, attr)
+		}
+	}
+	return n, nil
+}
+
+// Replaces a node with its children
+func unwrapNode(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = n.FirstChild {
+		n.RemoveChild(c)
+		n.Parent.InsertBefore(c, n)
+	}
+	n.Parent.RemoveChild(n)
+}
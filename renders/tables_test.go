@@ -0,0 +1,69 @@
+package renders
+
+import (
+	"testing"
+
+	"golang.org/x/net/html/atom"
+)
+
+func TestRenderTablesHeaderRow(t *testing.T) {
+	gr := &GdocRender{styles: parseStyles(`.c1{font-weight:700}.c2{font-weight:400}`)}
+	body := parseBody(t,
+		`<table><tbody>`+
+			`<tr><td><p><span class="c1">Product</span></p></td><td><p><span class="c1">Storage</span></p></td></tr>`+
+			`<tr><td><p><span class="c2">Cloud SQL</span></p></td><td><p><span class="c2">Relational</span></p></td></tr>`+
+			`</tbody></table>`)
+	gr.renderTables(body)
+
+	want := `<table><thead><tr><th><p><span class="c1">Product</span></p></th><th><p><span class="c1">Storage</span></p></th></tr></thead>` +
+		`<tbody><tr><td><p><span class="c2">Cloud SQL</span></p></td><td><p><span class="c2">Relational</span></p></td></tr></tbody></table>`
+	if got := renderChildren(t, body); got != want {
+		t.Fatalf("got %s\nwant %s", got, want)
+	}
+}
+
+func TestIsHeaderRow(t *testing.T) {
+	gr := &GdocRender{styles: parseStyles(`.c1{font-weight:700}.c2{background-color:#cccccc}`)}
+	for fragment, want := range map[string]bool{
+		`<tr><td><p><span class="c1">a</span></p></td></tr><tr><td><p>b</p></td></tr>`:       true,
+		`<tr><td class="c2"><p>a</p></td></tr><tr><td><p>b</p></td></tr>`:                    true,
+		`<tr><td class="c2"><p>a</p></td></tr><tr><td class="c2"><p>b</p></td></tr>`:         false,
+		`<tr><td><p><span class="c1">a</span> plain</p></td></tr><tr><td><p>b</p></td></tr>`: false,
+		`<tr><td><p>a</p></td></tr><tr><td><p><span class="c1">b</span></p></td></tr>`:       false,
+	} {
+		rows := findAll(parseBody(t, "<table>"+fragment+"</table>"), atom.Tr)
+		if got := gr.isHeaderRow(rows[0], rows[1]); got != want {
+			t.Fatalf("isHeaderRow(%s) = %v, want %v", fragment, got, want)
+		}
+	}
+}
+
+func TestCleanAtomCell(t *testing.T) {
+	body := parseBody(t,
+		`<table><tr>`+
+			`<td colspan="1" rowspan="1"><p><span>Cloud</span><span> SQL</span></p></td>`+
+			`<td colspan="2" rowspan="1"><p><span>b</span></p><p><span>c</span></p></td>`+
+			`</tr></table>`)
+	gr := new(GdocRender)
+	for _, td := range findAll(body, atom.Td) {
+		if _, err := gr.cleanAtomCell(td); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := `<table><tbody><tr><td>Cloud SQL</td><td colspan="2"><p><span>b</span></p><p><span>c</span></p></td></tr></tbody></table>`
+	if got := renderChildren(t, body); got != want {
+		t.Fatalf("got %s\nwant %s", got, want)
+	}
+}
+
+func TestCleanAtomTable(t *testing.T) {
+	body := parseBody(t, `<table><tr><td>a</td></tr></table>`)
+	wrapper, err := new(GdocRender).cleanAtomTable(body.FirstChild)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wrapper.Parent != body || wrapper.FirstChild.DataAtom != atom.Table || nodeAttr(wrapper, "class") != tableWrapperClass {
+		t.Fatalf("expected the table wrapped in place, got %s", renderChildren(t, body))
+	}
+}
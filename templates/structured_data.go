@@ -0,0 +1,233 @@
+package templates
+
+import (
+  "errors"
+  "fmt"
+  "net/url"
+  "time"
+  "github.com/cobookman/gcp-quickstart/layout"
+)
+
+// Structured data is rendered into every page as schema.org JSON-LD, see
+// https://developers.google.com/search/docs/guides/intro-structured-data
+// Note that this file is parsed along with the templates, so it must not
+// contain a template action.
+
+const (
+  schemaContext = "https://schema.org"
+  siteName = "GCP Quickstarts"
+)
+
+// Structured data which can check itself before it is rendered
+type StructuredData interface {
+  Validate() error
+}
+
+type Person struct {
+  Type string `json:"@type"`
+  Name string `json:"name"`
+}
+
+type ImageObject struct {
+  Type string `json:"@type"`
+  URL string `json:"url"`
+}
+
+type Organization struct {
+  Type string `json:"@type"`
+  Name string `json:"name"`
+  URL string `json:"url"`
+  Logo *ImageObject `json:"logo"`
+}
+
+type TechArticle struct {
+  Context string `json:"@context"`
+  Type string `json:"@type"`
+  Headline string `json:"headline"`
+  Description string `json:"description,omitempty"`
+  URL string `json:"url"`
+  MainEntityOfPage string `json:"mainEntityOfPage"`
+  Image []string `json:"image"`
+  DatePublished *time.Time `json:"datePublished,omitempty"`
+  DateModified *time.Time `json:"dateModified,omitempty"`
+  Author *Person `json:"author,omitempty"`
+  Publisher *Organization `json:"publisher"`
+  Keywords string `json:"keywords,omitempty"`
+  ProficiencyLevel string `json:"proficiencyLevel,omitempty"`
+  TimeRequired string `json:"timeRequired,omitempty"`
+}
+
+type WebPage struct {
+  Context string `json:"@context"`
+  Type string `json:"@type"`
+  Name string `json:"name"`
+  Description string `json:"description,omitempty"`
+  URL string `json:"url"`
+  DateModified *time.Time `json:"dateModified,omitempty"`
+  Publisher *Organization `json:"publisher"`
+}
+
+type ListItem struct {
+  Type string `json:"@type"`
+  Position int `json:"position"`
+  Name string `json:"name"`
+  Item string `json:"item"`
+}
+
+type BreadcrumbList struct {
+  Context string `json:"@context"`
+  Type string `json:"@type"`
+  ItemListElement []*ListItem `json:"itemListElement"`
+}
+
+// The site itself, as the publisher of every page
+func NewPublisher(domain string) *Organization {
+  return &Organization{
+    Type: "Organization",
+    Name: siteName,
+    URL: AbsoluteURL(domain, "/"),
+    Logo: &ImageObject{
+      Type: "ImageObject",
+      URL: AbsoluteURL(domain, DefaultImage),
+    },
+  }
+}
+
+// A lesson, or any other article teaching how to use GCP
+func NewTechArticle(pg *PageMetadata) *TechArticle {
+  return &TechArticle{
+    Context: schemaContext,
+    Type: "TechArticle",
+    Headline: pg.Title,
+    Description: pg.Description,
+    URL: pg.URL(),
+    MainEntityOfPage: pg.URL(),
+    Image: []string{pg.ImageURL()},
+    Publisher: NewPublisher(pg.Domain),
+  }
+}
+
+// A page which is a collection of other pages, such as a category
+func NewCollectionPage(pg *PageMetadata) *WebPage {
+  return &WebPage{
+    Context: schemaContext,
+    Type: "CollectionPage",
+    Name: pg.Title,
+    Description: pg.Description,
+    URL: pg.URL(),
+    Publisher: NewPublisher(pg.Domain),
+  }
+}
+
+func NewWebPage(pg *PageMetadata) *WebPage {
+  page := NewCollectionPage(pg)
+  page.Type = "WebPage"
+  return page
+}
+
+// Breadcrumbs from the home page down to the page, given as pairs of names
+// and site relative paths.
+func NewBreadcrumbList(domain string, crumbs ...string) *BreadcrumbList {
+  list := &BreadcrumbList{
+    Context: schemaContext,
+    Type: "BreadcrumbList",
+    ItemListElement: []*ListItem{
+      &ListItem{
+        Type: "ListItem",
+        Position: 1,
+        Name: siteName,
+        Item: AbsoluteURL(domain, "/"),
+      },
+    },
+  }
+  for i := 0; i+1 < len(crumbs); i += 2 {
+    list.ItemListElement = append(list.ItemListElement, &ListItem{
+      Type: "ListItem",
+      Position: len(list.ItemListElement) + 1,
+      Name: crumbs[i],
+      Item: AbsoluteURL(domain, crumbs[i+1]),
+    })
+  }
+  return list
+}
+
+func CategoryBreadcrumbs(domain string, category *layout.Category) *BreadcrumbList {
+  return NewBreadcrumbList(domain, category.Name, "/" + category.ID + "/index.html")
+}
+
+func ProductBreadcrumbs(domain string, product *layout.Product) *BreadcrumbList {
+  return NewBreadcrumbList(domain,
+    product.Category.Name, "/" + product.Category.ID + "/index.html",
+    product.Name, product.Href())
+}
+
+func LessonBreadcrumbs(domain string, lesson *layout.Lesson) *BreadcrumbList {
+  product := lesson.Product
+  return NewBreadcrumbList(domain,
+    product.Category.Name, "/" + product.Category.ID + "/index.html",
+    product.Name, product.Href(),
+    lesson.Name, lesson.Href)
+}
+
+// Formats a duration as ISO 8601, such as PT1H30M
+func ISODuration(d time.Duration) string {
+  if d <= 0 {
+    return ""
+  }
+  hours := int(d.Hours())
+  minutes := int(d.Minutes()) - hours * 60
+  duration := "PT"
+  if hours != 0 {
+    duration += fmt.Sprintf("%dH", hours)
+  }
+  if minutes != 0 || hours == 0 {
+    duration += fmt.Sprintf("%dM", minutes)
+  }
+  return duration
+}
+
+func (a *TechArticle) Validate() error {
+  if len(a.Headline) == 0 {
+    return errors.New("TechArticle has no headline")
+  }
+  if len(a.Image) == 0 {
+    return errors.New("TechArticle has no image: " + a.Headline)
+  }
+  if a.DatePublished != nil && a.DateModified != nil && a.DateModified.Before(*a.DatePublished) {
+    return errors.New("TechArticle is modified before it is published: " + a.Headline)
+  }
+  if a.Publisher == nil {
+    return errors.New("TechArticle has no publisher: " + a.Headline)
+  }
+  return validateURL(a.URL)
+}
+
+func (p *WebPage) Validate() error {
+  if len(p.Name) == 0 {
+    return errors.New(p.Type + " has no name")
+  }
+  return validateURL(p.URL)
+}
+
+func (l *BreadcrumbList) Validate() error {
+  for _, item := range l.ItemListElement {
+    if len(item.Name) == 0 {
+      return fmt.Errorf("breadcrumb %d has no name", item.Position)
+    }
+    if err := validateURL(item.Item); err != nil {
+      return err
+    }
+  }
+  return nil
+}
+
+func validateURL(u string) error {
+  parsed, err := url.Parse(u)
+  if err != nil {
+    return err
+  }
+  if !parsed.IsAbs() {
+    return errors.New("structured data url is not absolute: " + u)
+  }
+  return nil
+}
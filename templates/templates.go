@@ -1,10 +1,12 @@
 package templates
 
 import (
+  "fmt"
   "os"
   "html/template"
-  "time"
   "path/filepath"
+  "strings"
+  "time"
   "github.com/cobookman/gcp-quickstart/layout"
 )
 
@@ -12,21 +14,112 @@ var (
   templates *template.Template
 )
 
-type Social struct {
-  Context       string    `json:"@context"`
-	Type          string    `json:"@type"`
-	Headline      string    `json:"headline"`
-	DatePublished time.Time `json:"datePublished"`
-	Image         []string  `json:"iamge"`
+// Open Graph types of pages
+const (
+  TypeWebsite = "website"
+  TypeArticle = "article"
+)
+
+// Shared by social media when a page has no image of its own
+const DefaultImage = "/statics/img/icons/Extras/Generic-GCP.png"
+
+// Where a page's table of contents is placed
+const (
+  TOCTop = "top"
+  TOCSideBar = "side-bar"
+)
+
+type TOCEntry struct {
+  Level int
+  ID string
+  Text string
+}
+
+// A step of a lesson which is split into several pages
+type Step struct {
+  Number int
+  Title string
+  FilePath string
+  // Estimated time to go through the step
+  Duration time.Duration
 }
 
 type PageMetadata struct {
   Title string
+  Description string
+  Author string
+  // Path or absolute url of the page's image
+  Image string
+  // Open Graph type of the page
+  Type string
+  // Overrides the page's own url as its canonical url
+  Canonical string
   ArticleHTML template.HTML
   FilePath string
   Domain string
-  Social *Social
+  // When the page's source was last modified, shown on the page
+  Updated time.Time
+  // Deprecated pages are still published, with a notice they are no longer
+  // kept up to date
+  Deprecated bool
+  StructuredData []StructuredData
   Layout *layout.Layout
+  TOC []*TOCEntry
+  TOCPosition string
+  // AMP components the article uses on top of amp-sidebar, e.g. amp-youtube
+  AMPComponents []string
+  // All steps of the lesson, if the page is one of them
+  Steps []*Step
+  StepNumber int
+}
+
+// Absolute url of the page
+func (pg *PageMetadata) URL() string {
+  if len(pg.Canonical) != 0 {
+    return pg.Canonical
+  }
+  return AbsoluteURL(pg.Domain, pg.FilePath)
+}
+
+// Absolute url of the page's image, or of the default image
+func (pg *PageMetadata) ImageURL() string {
+  if len(pg.Image) == 0 {
+    return AbsoluteURL(pg.Domain, DefaultImage)
+  }
+  return AbsoluteURL(pg.Domain, pg.Image)
+}
+
+func (pg *PageMetadata) PrevStep() *Step {
+  if pg.StepNumber <= 1 || pg.StepNumber > len(pg.Steps) {
+    return nil
+  }
+  return pg.Steps[pg.StepNumber - 2]
+}
+
+func (pg *PageMetadata) NextStep() *Step {
+  if pg.StepNumber < 1 || pg.StepNumber >= len(pg.Steps) {
+    return nil
+  }
+  return pg.Steps[pg.StepNumber]
+}
+
+// Estimated minutes left in the lesson, counting the current step
+func (pg *PageMetadata) MinutesLeft() int {
+  var left time.Duration
+  for _, step := range pg.Steps {
+    if step.Number >= pg.StepNumber {
+      left += step.Duration
+    }
+  }
+  return int((left + time.Minute - 1) / time.Minute)
+}
+
+// Joins a site relative path onto the domain. Absolute urls are kept as is.
+func AbsoluteURL(domain string, path string) string {
+  if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+    return path
+  }
+  return strings.TrimRight(domain, "/") + "/" + strings.TrimLeft(path, "/")
 }
 
 func RenderHTML(html string) (template.HTML) {
@@ -53,10 +146,17 @@ func getFile(pg *PageMetadata, buildFolder string) (*os.File, error) {
 }
 
 func RenderPage(pg *PageMetadata, buildFolder string) error {
+  for _, data := range pg.StructuredData {
+    if err := data.Validate(); err != nil {
+      return fmt.Errorf("%s: %v", pg.FilePath, err)
+    }
+  }
+
   f, err := getFile(pg, buildFolder)
   if err != nil {
     return err
   }
+  defer f.Close()
 
   return Templates().ExecuteTemplate(f, "page", pg)
 }